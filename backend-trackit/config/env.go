@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 	"github.com/joho/godotenv"
 )
 
@@ -16,3 +19,88 @@ func LoadEnv() {
 		}
 	}
 }
+
+// RequireIfMatch reports whether task updates and deletes must send an
+// If-Match header, enabled with REQUIRE_IF_MATCH=true
+func RequireIfMatch() bool {
+	strict, _ := strconv.ParseBool(os.Getenv("REQUIRE_IF_MATCH"))
+	return strict
+}
+
+// IsAdmin reports whether the user may change server-wide settings such as
+// the workflow. Admins are listed by ID, comma separated, in ADMIN_USER_IDS.
+func IsAdmin(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	for _, id := range strings.Split(os.Getenv("ADMIN_USER_IDS"), ",") {
+		if strings.TrimSpace(id) == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// TrashRetention is how long deleted tasks stay in the trash before they are
+// purged, set in days with TRASH_RETENTION_DAYS (default 30)
+func TrashRetention() time.Duration {
+	days := 30
+	if raw := os.Getenv("TRASH_RETENTION_DAYS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			log.Printf("Warning: invalid TRASH_RETENTION_DAYS %q, using %d", raw, days)
+		} else {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ArchiveAfter is how long tasks stay done before they are archived
+// automatically, set in days with ARCHIVE_AFTER_DAYS (default 30). Zero
+// turns auto-archiving off.
+func ArchiveAfter() time.Duration {
+	days := 30
+	if raw := os.Getenv("ARCHIVE_AFTER_DAYS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			log.Printf("Warning: invalid ARCHIVE_AFTER_DAYS %q, using %d", raw, days)
+		} else {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// AttachmentStorage names where attachment contents are kept, "local" (the
+// default) or "gridfs", set with ATTACHMENT_STORAGE
+func AttachmentStorage() string {
+	if kind := os.Getenv("ATTACHMENT_STORAGE"); kind != "" {
+		return kind
+	}
+	return "local"
+}
+
+// AttachmentDir is the directory local attachment storage writes to, set
+// with ATTACHMENT_DIR (default ./uploads)
+func AttachmentDir() string {
+	if dir := os.Getenv("ATTACHMENT_DIR"); dir != "" {
+		return dir
+	}
+	return "uploads"
+}
+
+// MaxAttachmentSize is the largest attachment accepted, in bytes, set in
+// megabytes with ATTACHMENT_MAX_MB (default 25)
+func MaxAttachmentSize() int64 {
+	megabytes := 25
+	if raw := os.Getenv("ATTACHMENT_MAX_MB"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			log.Printf("Warning: invalid ATTACHMENT_MAX_MB %q, using %d", raw, megabytes)
+		} else {
+			megabytes = parsed
+		}
+	}
+	return int64(megabytes) << 20
+}
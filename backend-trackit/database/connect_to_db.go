@@ -52,6 +52,18 @@ func InitDatabase() {
     Client = client
 
     log.Println("✅ Successfully connected to MongoDB:", dbName)
+
+    // Bring stored documents up to date, then create the indexes the
+    // query handlers rely on
+    setupCtx, setupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer setupCancel()
+
+    if err := RunMigrations(setupCtx); err != nil {
+        log.Fatalf("Failed to migrate MongoDB documents: %v", err)
+    }
+    if err := EnsureIndexes(setupCtx); err != nil {
+        log.Fatalf("Failed to create MongoDB indexes: %v", err)
+    }
 }
 
 // GetCollection returns a reference to the specified MongoDB collection
@@ -0,0 +1,127 @@
+package database
+
+import (
+    "context"
+    "fmt"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TaskSortFields lists the stored task fields that GET /api/tasks can sort
+// on; priority and status sort through their numeric rank fields. Every
+// entry gets a compound index per visibility branch so cursor pagination
+// never falls back to an in-memory sort.
+var TaskSortFields = []string{"created_at", "updated_at", "due_date", "priority_rank", "status_rank", "title", "rank", "completed_at", "archived_at"}
+
+// collectionIndexes returns the indexes that must exist for each collection
+func collectionIndexes() map[string][]mongo.IndexModel {
+    taskIndexes := []mongo.IndexModel{
+        {Keys: bson.D{{Key: "status", Value: 1}}},
+        {Keys: bson.D{{Key: "priority", Value: 1}}},
+        {Keys: bson.D{{Key: "tags", Value: 1}}},
+        {Keys: bson.D{{Key: "due_date", Value: 1}}},
+        {Keys: bson.D{{Key: "parent_id", Value: 1}, {Key: "status", Value: 1}}},
+        {Keys: bson.D{{Key: "status", Value: 1}, {Key: "rank", Value: 1}, {Key: "_id", Value: 1}}},
+        {Keys: bson.D{{Key: "blocked_by", Value: 1}}},
+        {Keys: bson.D{{Key: "deleted_at", Value: 1}}},
+        // Backs the auto-archive job
+        {Keys: bson.D{{Key: "status", Value: 1}, {Key: "completed_at", Value: 1}}},
+        {Keys: bson.D{{Key: "deleted_with", Value: 1}}, Options: options.Index().SetSparse(true)},
+        {
+            // Custom fields are defined at runtime, so one wildcard index
+            // backs filtering and sorting on any of them
+            Keys: bson.D{{Key: "custom_fields.$**", Value: 1}},
+        },
+        {
+            // A recurring task creates at most one task per occurrence
+            Keys: bson.D{{Key: "recurrence.id", Value: 1}, {Key: "recurrence.occurrence", Value: 1}},
+            Options: options.Index().
+                SetUnique(true).
+                SetPartialFilterExpression(bson.M{"recurrence.id": bson.M{"$exists": true}}),
+        },
+        {
+            // Backs GET /api/tasks/search; a collection may only have one text index
+            Keys: bson.D{
+                {Key: "title", Value: "text"},
+                {Key: "description", Value: "text"},
+                {Key: "tags", Value: "text"},
+            },
+            Options: options.Index().
+                SetName("task_text_search").
+                SetWeights(bson.D{
+                    {Key: "title", Value: 10},
+                    {Key: "tags", Value: 5},
+                    {Key: "description", Value: 1},
+                }),
+        },
+    }
+
+    // The visibility rule is "created_by OR assigned_to", so each sortable
+    // field is indexed behind both owner keys, with _id as the tie-breaker.
+    for _, owner := range []string{"created_by", "assigned_to"} {
+        for _, field := range TaskSortFields {
+            taskIndexes = append(taskIndexes, mongo.IndexModel{
+                Keys: bson.D{
+                    {Key: owner, Value: 1},
+                    {Key: field, Value: 1},
+                    {Key: "_id", Value: 1},
+                },
+            })
+        }
+    }
+
+    return map[string][]mongo.IndexModel{
+        "tasks": taskIndexes,
+        "activity": {
+            {Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "_id", Value: -1}}},
+        },
+        "comments": {
+            {Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}}},
+            {Keys: bson.D{{Key: "parent_id", Value: 1}}},
+        },
+        "recurring_tasks": {
+            {Keys: bson.D{{Key: "active", Value: 1}, {Key: "next_due", Value: 1}}},
+            {Keys: bson.D{{Key: "created_by", Value: 1}, {Key: "next_due", Value: 1}}},
+        },
+        "time_entries": {
+            {Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "started_at", Value: -1}}},
+            {Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: 1}}},
+            {
+                // A user has at most one running timer
+                Keys: bson.D{{Key: "user_id", Value: 1}},
+                Options: options.Index().
+                    SetUnique(true).
+                    SetPartialFilterExpression(bson.M{"running": true}),
+            },
+        },
+        "custom_fields": {
+            {Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+        },
+        "templates": {
+            {Keys: bson.D{{Key: "created_by", Value: 1}, {Key: "name", Value: 1}}},
+        },
+        "workflows": {
+            {Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+        },
+        "views": {
+            {Keys: bson.D{{Key: "created_by", Value: 1}, {Key: "name", Value: 1}}},
+            {Keys: bson.D{{Key: "shared", Value: 1}, {Key: "name", Value: 1}}},
+        },
+    }
+}
+
+// EnsureIndexes creates any missing indexes. Creating an index that already
+// exists with the same keys and options is a no-op in MongoDB.
+func EnsureIndexes(ctx context.Context) error {
+    for name, indexes := range collectionIndexes() {
+        if len(indexes) == 0 {
+            continue
+        }
+        if _, err := DB.Collection(name).Indexes().CreateMany(ctx, indexes); err != nil {
+            return fmt.Errorf("creating indexes on %s: %w", name, err)
+        }
+    }
+    return nil
+}
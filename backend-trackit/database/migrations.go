@@ -0,0 +1,121 @@
+package database
+
+import (
+    "context"
+    "fmt"
+    "log"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+
+    "backend-trackit/models"
+)
+
+// migration rewrites stored documents into the shape the current models
+// expect. Every migration must be idempotent because all of them run on
+// each startup.
+type migration struct {
+    name string
+    run  func(ctx context.Context, db *mongo.Database) (int64, error)
+}
+
+var migrations = []migration{
+    {
+        // Tasks used to hold a single assignee ID; they now hold an array
+        name: "tasks.assigned_to to array",
+        run: func(ctx context.Context, db *mongo.Database) (int64, error) {
+            result, err := db.Collection("tasks").UpdateMany(ctx,
+                bson.M{"assigned_to": bson.M{"$type": "objectId"}},
+                mongo.Pipeline{{{Key: "$set", Value: bson.M{"assigned_to": bson.A{"$assigned_to"}}}}},
+            )
+            if err != nil {
+                return 0, err
+            }
+            return result.ModifiedCount, nil
+        },
+    },
+    {
+        // Optimistic concurrency needs a version on every task
+        name: "tasks.version",
+        run: func(ctx context.Context, db *mongo.Database) (int64, error) {
+            result, err := db.Collection("tasks").UpdateMany(ctx,
+                bson.M{"version": bson.M{"$exists": false}},
+                bson.M{"$set": bson.M{"version": 1}},
+            )
+            if err != nil {
+                return 0, err
+            }
+            return result.ModifiedCount, nil
+        },
+    },
+    {
+        // "completed" was the done status before the status enum; new writes
+        // already map it to done
+        name: "tasks.status completed to done",
+        run: func(ctx context.Context, db *mongo.Database) (int64, error) {
+            result, err := db.Collection("tasks").UpdateMany(ctx,
+                bson.M{"status": "completed"},
+                bson.M{"$set": bson.M{"status": models.StatusDone, "status_rank": models.StatusRank(models.StatusDone)}},
+            )
+            if err != nil {
+                return 0, err
+            }
+            return result.ModifiedCount, nil
+        },
+    },
+    {
+        // Auto-archiving needs to know when done tasks were completed. Use
+        // the transition into done if there is one, else the last update.
+        name: "tasks.completed_at",
+        run: func(ctx context.Context, db *mongo.Database) (int64, error) {
+            result, err := db.Collection("tasks").UpdateMany(ctx,
+                bson.M{"status": "done", "completed_at": bson.M{"$exists": false}},
+                mongo.Pipeline{{{Key: "$set", Value: bson.M{"completed_at": bson.M{
+                    "$cond": bson.A{
+                        bson.M{"$eq": bson.A{"$last_transition.to", "done"}},
+                        "$last_transition.at",
+                        "$updated_at",
+                    },
+                }}}}},
+            )
+            if err != nil {
+                return 0, err
+            }
+            return result.ModifiedCount, nil
+        },
+    },
+    {
+        // Priority and status sort through numeric ranks in enum order
+        name: "tasks.priority_rank and status_rank",
+        run: func(ctx context.Context, db *mongo.Database) (int64, error) {
+            result, err := db.Collection("tasks").UpdateMany(ctx,
+                bson.M{"$or": bson.A{
+                    bson.M{"priority_rank": bson.M{"$exists": false}},
+                    bson.M{"status_rank": bson.M{"$exists": false}},
+                }},
+                mongo.Pipeline{{{Key: "$set", Value: bson.M{
+                    "priority_rank": bson.M{"$indexOfArray": bson.A{models.TaskPriorities, "$priority"}},
+                    "status_rank":   bson.M{"$indexOfArray": bson.A{models.TaskStatuses, "$status"}},
+                }}}},
+            )
+            if err != nil {
+                return 0, err
+            }
+            return result.ModifiedCount, nil
+        },
+    },
+}
+
+// RunMigrations applies every migration in order
+func RunMigrations(ctx context.Context) error {
+    for _, m := range migrations {
+        modified, err := m.run(ctx, DB)
+        if err != nil {
+            return fmt.Errorf("migration %q: %w", m.name, err)
+        }
+        if modified > 0 {
+            log.Printf("Migration %q updated %d documents", m.name, modified)
+        }
+    }
+    return nil
+}
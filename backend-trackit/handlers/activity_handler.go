@@ -0,0 +1,187 @@
+package handlers
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "reflect"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+const activityCollection = "activity"
+
+const (
+    defaultActivityLimit = 50
+    maxActivityLimit     = 200
+)
+
+// untrackedTaskFields change as a side effect of other edits and are left
+// out of the history
+var untrackedTaskFields = map[string]bool{
+    "updated_at":      true,
+    "last_transition": true,
+    "subtasks":        true,
+    "rank":            true,
+    "completed_at":    true,
+    "priority_rank":   true,
+    "status_rank":     true,
+}
+
+// GetTaskActivity returns a task's history, newest first. Pass the
+// next_cursor of one page as the before parameter to get the next one.
+func GetTaskActivity(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    limit := int64(defaultActivityLimit)
+    if raw := c.Query("limit"); raw != "" {
+        parsed, err := strconv.ParseInt(raw, 10, 64)
+        if err != nil || parsed < 1 || parsed > maxActivityLimit {
+            c.JSON(400, gin.H{"error": fmt.Sprintf("invalid limit %q: must be between 1 and %d", raw, maxActivityLimit)})
+            return
+        }
+        limit = parsed
+    }
+
+    filter := bson.M{"task_id": taskID}
+    if raw := c.Query("before"); raw != "" {
+        before, err := primitive.ObjectIDFromHex(raw)
+        if err != nil {
+            c.JSON(400, gin.H{"error": "invalid before cursor"})
+            return
+        }
+        filter["_id"] = bson.M{"$lt": before}
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if _, _, err := loadTaskForUser(ctx, taskID, userID); err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    cursor, err := database.GetCollection(activityCollection).Find(ctx, filter,
+        options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit+1),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch activity", err)
+        return
+    }
+
+    activity := []models.Activity{}
+    if err := cursor.All(ctx, &activity); err != nil {
+        respondWithError(c, 500, "Failed to fetch activity", err)
+        return
+    }
+
+    var nextCursor *string
+    if int64(len(activity)) > limit {
+        activity = activity[:limit]
+        last := activity[len(activity)-1].ID.Hex()
+        nextCursor = &last
+    }
+
+    c.JSON(200, gin.H{"activity": activity, "next_cursor": nextCursor})
+}
+
+// ------------------ Helper Functions ------------------
+
+// newActivity starts a history entry for a task
+func newActivity(taskID, actorID primitive.ObjectID, activityType string) models.Activity {
+    return models.Activity{
+        ID:        primitive.NewObjectID(),
+        TaskID:    taskID,
+        ActorID:   actorID,
+        Type:      activityType,
+        CreatedAt: time.Now(),
+    }
+}
+
+// Append entries to the history. The change they describe has already been
+// written, so a failure here is logged rather than reported to the caller.
+func recordActivity(ctx context.Context, entries ...models.Activity) {
+    if len(entries) == 0 {
+        return
+    }
+
+    docs := make([]interface{}, len(entries))
+    for i, entry := range entries {
+        docs[i] = entry
+    }
+    if _, err := database.GetCollection(activityCollection).InsertMany(ctx, docs); err != nil {
+        log.Printf("Failed to record activity for task %s: %v", entries[0].TaskID.Hex(), err)
+    }
+}
+
+// Compare a task with the $set and $unset documents applied to it and list
+// the fields whose stored value actually changes
+func taskFieldChanges(task *models.Task, set, unset bson.M) []models.FieldChange {
+    // Encode both sides the way they are stored so values compare equal
+    // regardless of their Go types
+    before, err := toBSONDocument(task)
+    if err != nil {
+        log.Printf("Failed to diff task %s: %v", task.ID.Hex(), err)
+        return nil
+    }
+    after, err := toBSONDocument(set)
+    if err != nil {
+        log.Printf("Failed to diff task %s: %v", task.ID.Hex(), err)
+        return nil
+    }
+
+    var changes []models.FieldChange
+    for field, to := range after {
+        from := lookupBSONPath(before, field)
+        if untrackedTaskFields[field] || reflect.DeepEqual(from, to) {
+            continue
+        }
+        changes = append(changes, models.FieldChange{Field: field, From: from, To: to})
+    }
+    for field := range unset {
+        if from := lookupBSONPath(before, field); from != nil && !untrackedTaskFields[field] {
+            changes = append(changes, models.FieldChange{Field: field, From: from})
+        }
+    }
+
+    sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+    return changes
+}
+
+// lookupBSONPath returns the value at a dotted field path such as
+// custom_fields.points, or nil when it is missing
+func lookupBSONPath(doc bson.M, path string) interface{} {
+    var value interface{} = doc
+    for _, key := range strings.Split(path, ".") {
+        nested, ok := value.(bson.M)
+        if !ok {
+            return nil
+        }
+        value = nested[key]
+    }
+    return value
+}
+
+// toBSONDocument round-trips a value through the bson codec
+func toBSONDocument(value interface{}) (bson.M, error) {
+    data, err := bson.Marshal(value)
+    if err != nil {
+        return nil, err
+    }
+    var doc bson.M
+    if err := bson.Unmarshal(data, &doc); err != nil {
+        return nil, err
+    }
+    return doc, nil
+}
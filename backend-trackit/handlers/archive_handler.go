@@ -0,0 +1,244 @@
+package handlers
+
+import (
+    "context"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+const (
+    eventTaskArchived   = "task_archived"
+    eventTaskUnarchived = "task_unarchived"
+)
+
+// archiveBatchSize bounds how many tasks one auto-archive pass handles at a time
+const archiveBatchSize = 500
+
+// ArchiveTask archives a task so it drops out of task listings. The task
+// keeps its comments and history and can still be opened by ID.
+func ArchiveTask(c *gin.Context) {
+    setTaskArchived(c, true)
+}
+
+// UnarchiveTask brings an archived task back into task listings
+func UnarchiveTask(c *gin.Context) {
+    setTaskArchived(c, false)
+}
+
+// GetArchive lists the user's archived tasks, most recently archived first.
+// With the q parameter it searches them instead, answering like
+// GET /api/tasks/search.
+func GetArchive(c *gin.Context) {
+    userID := currentUserID(c)
+
+    limit, offset, err := parseSearchPaging(c)
+    if err != nil {
+        c.JSON(400, gin.H{"error": err.Error()})
+        return
+    }
+
+    if q := strings.TrimSpace(c.Query("q")); q != "" {
+        hits, err := searchUserTasks(userID, q, archivedTasksFilter(), limit, offset)
+        if err != nil {
+            respondWithError(c, 500, "Failed to search archive", err)
+            return
+        }
+        c.JSON(200, gin.H{"results": searchResults(q, hits), "limit": limit, "offset": offset})
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    cursor, err := database.GetCollection(taskCollection).Find(ctx,
+        bson.M{"$and": []bson.M{visibleTasksFilter(userID), archivedTasksFilter()}},
+        options.Find().
+            SetSort(bson.D{{Key: "archived_at", Value: -1}, {Key: "_id", Value: -1}}).
+            SetSkip(offset).
+            SetLimit(limit),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch archive", err)
+        return
+    }
+
+    tasks := []models.Task{}
+    if err := cursor.All(ctx, &tasks); err != nil {
+        respondWithError(c, 500, "Failed to fetch archive", err)
+        return
+    }
+    for i := range tasks {
+        tasks[i].FillChecklistRatio()
+    }
+
+    c.JSON(200, gin.H{"tasks": tasks, "limit": limit, "offset": offset})
+}
+
+// ArchiveCompletedTasks archives tasks that have been done for longer than
+// after. A task that was unarchived by hand is only archived again once it
+// has also been out of the archive that long. It returns the number of
+// tasks archived.
+func ArchiveCompletedTasks(ctx context.Context, after time.Duration) (int64, error) {
+    collection := database.GetCollection(taskCollection)
+    due := autoArchiveFilter(time.Now().Add(-after))
+
+    var archived int64
+    for {
+        ids, err := findTaskIDs(ctx, due, archiveBatchSize)
+        if err != nil {
+            return archived, err
+        }
+        if len(ids) == 0 {
+            return archived, nil
+        }
+
+        now := time.Now()
+        filter := bson.M{"$and": []bson.M{{"_id": bson.M{"$in": ids}}, due}}
+        if _, err := collection.UpdateMany(ctx, filter, bson.M{
+            "$set": bson.M{"archived_at": now, "updated_at": now},
+            "$inc": bson.M{"version": 1},
+        }); err != nil {
+            return archived, err
+        }
+
+        // A task edited since it was found is left alone, so only record
+        // the ones this pass archived
+        done, err := findTaskIDs(ctx, bson.M{"_id": bson.M{"$in": ids}, "archived_at": now}, 0)
+        if err != nil {
+            return archived, err
+        }
+        entries := make([]models.Activity, len(done))
+        for i, id := range done {
+            entries[i] = newActivity(id, primitive.NilObjectID, models.ActivityTaskArchived)
+        }
+        recordActivity(ctx, entries...)
+        archived += int64(len(done))
+
+        if len(ids) < archiveBatchSize {
+            return archived, nil
+        }
+    }
+}
+
+// ------------------ Helper Functions ------------------
+
+// archivedTasksFilter matches archived tasks
+func archivedTasksFilter() bson.M {
+    return bson.M{"archived_at": bson.M{"$ne": nil}}
+}
+
+// unarchivedTasksFilter matches the tasks that are not archived
+func unarchivedTasksFilter() bson.M {
+    return bson.M{"archived_at": nil}
+}
+
+// autoArchiveFilter matches the tasks the auto-archive policy archives when
+// tasks completed and unarchived before cutoff are due
+func autoArchiveFilter(cutoff time.Time) bson.M {
+    return bson.M{
+        "status":       models.StatusDone,
+        "completed_at": bson.M{"$lt": cutoff},
+        "archived_at":  nil,
+        "deleted_at":   nil,
+        "$or": []bson.M{
+            {"unarchived_at": nil},
+            {"unarchived_at": bson.M{"$lt": cutoff}},
+        },
+    }
+}
+
+// Archive or unarchive the task named in the path for its creator
+func setTaskArchived(c *gin.Context, archive bool) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    if !role.CanArchive() {
+        c.JSON(403, gin.H{"error": "Only the task creator can archive this task"})
+        return
+    }
+    if archive == (task.ArchivedAt != nil) {
+        if archive {
+            c.JSON(409, gin.H{"error": "Task is already archived"})
+        } else {
+            c.JSON(409, gin.H{"error": "Task is not archived"})
+        }
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+
+    now := time.Now()
+    filter := taskAccessFilter(task.ID, userID, role)
+    if version != nil {
+        filter["version"] = *version
+    }
+    set := bson.M{"updated_at": now}
+    update := bson.M{"$set": set}
+    activityType, event, message := models.ActivityTaskArchived, eventTaskArchived, "Task archived successfully"
+    if archive {
+        filter["archived_at"] = nil
+        set["archived_at"] = now
+        set["archived_by"] = userID
+    } else {
+        filter["archived_at"] = bson.M{"$ne": nil}
+        set["unarchived_at"] = now
+        update["$unset"] = bson.M{"archived_at": "", "archived_by": ""}
+        activityType, event, message = models.ActivityTaskUnarchived, eventTaskUnarchived, "Task unarchived successfully"
+    }
+
+    matchedCount, err := updateTask(filter, update)
+    if err != nil {
+        respondWithError(c, 500, "Failed to update task", err)
+        return
+    }
+    if matchedCount == 0 {
+        respondWithTaskUpdateError(c, staleTaskError(ctx, task, userID))
+        return
+    }
+
+    recordActivity(ctx, newActivity(task.ID, userID, activityType))
+    notifyTaskAudience(task, event, gin.H{"id": task.ID, "by": userID}, userID)
+    respondWithUpdatedTask(c, task.ID, userID, message)
+}
+
+// Find the IDs of up to limit tasks matching filter; a limit of 0 means no limit
+func findTaskIDs(ctx context.Context, filter bson.M, limit int64) ([]primitive.ObjectID, error) {
+    opts := options.Find().SetProjection(bson.M{"_id": 1})
+    if limit > 0 {
+        opts.SetLimit(limit)
+    }
+    cursor, err := database.GetCollection(taskCollection).Find(ctx, filter, opts)
+    if err != nil {
+        return nil, err
+    }
+    var found []struct {
+        ID primitive.ObjectID `bson:"_id"`
+    }
+    if err := cursor.All(ctx, &found); err != nil {
+        return nil, err
+    }
+
+    ids := make([]primitive.ObjectID, len(found))
+    for i, t := range found {
+        ids[i] = t.ID
+    }
+    return ids, nil
+}
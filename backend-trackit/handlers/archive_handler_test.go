@@ -0,0 +1,180 @@
+package handlers
+
+import (
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+const archiveAfter = 30 * 24 * time.Hour
+
+// A task unarchived by hand stays out of the archive on the next auto-archive
+// pass, even though it was completed long ago
+func TestUnarchivedTaskSurvivesAutoArchive(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+    mt.Run("unarchive", func(mt *mtest.T) {
+        database.DB = mt.DB
+
+        creator := primitive.NewObjectID()
+        completed := time.Now().Add(-60 * 24 * time.Hour)
+        archived := time.Now().Add(-20 * 24 * time.Hour)
+        task := models.Task{
+            ID:          primitive.NewObjectID(),
+            Title:       "Ship the release",
+            Status:      models.StatusDone,
+            Priority:    models.PriorityMedium,
+            CreatedBy:   creator,
+            CreatedAt:   completed,
+            UpdatedAt:   archived,
+            CompletedAt: &completed,
+            ArchivedAt:  &archived,
+            Version:     3,
+        }
+        taskDoc := mustBSOND(mt, task)
+        mt.AddMockResponses(
+            mtest.CreateCursorResponse(0, "trackit."+taskCollection, mtest.FirstBatch, taskDoc),
+            mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+            mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+            mtest.CreateCursorResponse(0, "trackit."+taskCollection, mtest.FirstBatch, taskDoc),
+        )
+
+        w := httptest.NewRecorder()
+        c, _ := gin.CreateTestContext(w)
+        c.Request = httptest.NewRequest("POST", "/api/tasks/"+task.ID.Hex()+"/unarchive", nil)
+        c.Params = gin.Params{{Key: "id", Value: task.ID.Hex()}}
+        c.Set("userId", creator.Hex())
+
+        UnarchiveTask(c)
+        if w.Code != 200 {
+            mt.Fatalf("status = %d, want 200; body %s", w.Code, w.Body.String())
+        }
+
+        var unarchivedAt *time.Time
+        for _, event := range mt.GetAllStartedEvents() {
+            if event.CommandName != "update" {
+                continue
+            }
+            set, ok := event.Command.Lookup("updates", "0", "u", "$set").DocumentOK()
+            if !ok {
+                continue
+            }
+            if value, ok := set.Lookup("unarchived_at").DateTimeOK(); ok {
+                at := time.UnixMilli(value)
+                unarchivedAt = &at
+            }
+        }
+        if unarchivedAt == nil {
+            mt.Fatal("unarchiving did not record unarchived_at")
+        }
+
+        after := task
+        after.ArchivedAt = nil
+        after.UnarchivedAt = unarchivedAt
+        if matchesTestFilter(mt, after, autoArchiveFilter(time.Now().Add(-archiveAfter))) {
+            mt.Error("the next auto-archive pass would archive the task again")
+        }
+    })
+}
+
+func TestAutoArchiveFilter(t *testing.T) {
+    now := time.Now()
+    daysAgo := func(days int) *time.Time {
+        at := now.Add(-time.Duration(days) * 24 * time.Hour)
+        return &at
+    }
+
+    tests := []struct {
+        name string
+        task models.Task
+        due  bool
+    }{
+        {"done long ago", models.Task{Status: models.StatusDone, CompletedAt: daysAgo(60)}, true},
+        {"done recently", models.Task{Status: models.StatusDone, CompletedAt: daysAgo(5)}, false},
+        {"not done", models.Task{Status: models.StatusInProgress, CompletedAt: daysAgo(60)}, false},
+        {"already archived", models.Task{Status: models.StatusDone, CompletedAt: daysAgo(60), ArchivedAt: daysAgo(20)}, false},
+        {"in the trash", models.Task{Status: models.StatusDone, CompletedAt: daysAgo(60), DeletedAt: daysAgo(1)}, false},
+        {"unarchived recently", models.Task{Status: models.StatusDone, CompletedAt: daysAgo(60), UnarchivedAt: daysAgo(5)}, false},
+        {"unarchived long ago", models.Task{Status: models.StatusDone, CompletedAt: daysAgo(90), UnarchivedAt: daysAgo(60)}, true},
+    }
+    filter := autoArchiveFilter(now.Add(-archiveAfter))
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := matchesTestFilter(t, tt.task, filter); got != tt.due {
+                t.Errorf("due = %v, want %v", got, tt.due)
+            }
+        })
+    }
+}
+
+func mustBSOND(t testing.TB, value interface{}) bson.D {
+    t.Helper()
+    data, err := bson.Marshal(value)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var doc bson.D
+    if err := bson.Unmarshal(data, &doc); err != nil {
+        t.Fatal(err)
+    }
+    return doc
+}
+
+// matchesTestFilter evaluates the subset of the query language the archive
+// filters use (top-level fields, $or, $lt and null equality) against a task
+func matchesTestFilter(t testing.TB, task models.Task, filter bson.M) bool {
+    t.Helper()
+    doc, err := toBSONDocument(task)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return matchesDocument(t, doc, filter)
+}
+
+func matchesDocument(t testing.TB, doc, filter bson.M) bool {
+    t.Helper()
+    for key, condition := range filter {
+        switch key {
+        case "$or":
+            matched := false
+            for _, branch := range condition.([]bson.M) {
+                matched = matched || matchesDocument(t, doc, branch)
+            }
+            if !matched {
+                return false
+            }
+            continue
+        }
+
+        value := doc[key]
+        switch condition := condition.(type) {
+        case nil:
+            if value != nil {
+                return false
+            }
+        case bson.M:
+            bound, ok := condition["$lt"].(time.Time)
+            if !ok || len(condition) != 1 {
+                t.Fatalf("unsupported condition on %s: %v", key, condition)
+            }
+            at, ok := value.(primitive.DateTime)
+            if !ok || !at.Time().Before(bound) {
+                return false
+            }
+        default:
+            if value != condition {
+                return false
+            }
+        }
+    }
+    return true
+}
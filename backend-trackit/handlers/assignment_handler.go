@@ -0,0 +1,300 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+// Websocket event types sent to assignees
+const (
+    eventTaskAssigned   = "task_assigned"
+    eventTaskUnassigned = "task_unassigned"
+)
+
+// AssignTask adds users to a task's assignees, or replaces the current
+// assignees when replace is true
+func AssignTask(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        UserIDs []string `json:"user_ids" binding:"required,min=1"`
+        Replace bool     `json:"replace"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    assignees, errs := parseAssignees(input.UserIDs)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    if !role.CanUpdateField("assigned_to") {
+        c.JSON(403, gin.H{"error": "Only the task creator can change assignees"})
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+
+    if errs, err := validateAssigneesExist(ctx, assignees); err != nil {
+        respondWithError(c, 500, "Failed to look up users", err)
+        return
+    } else if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    if !input.Replace && len(mergeAssignees(task.AssignedTo, assignees)) > models.MaxAssignees {
+        respondWithValidationErrors(c, models.ValidationErrors{{
+            Field:   "user_ids",
+            Message: fmt.Sprintf("a task can have at most %d assignees", models.MaxAssignees),
+        }})
+        return
+    }
+
+    filter := taskAccessFilter(taskID, userID, role)
+    if version != nil {
+        filter["version"] = *version
+    }
+    update := bson.M{"$set": bson.M{"updated_at": time.Now()}}
+    if input.Replace {
+        update["$set"].(bson.M)["assigned_to"] = assignees
+    } else {
+        update["$addToSet"] = bson.M{"assigned_to": bson.M{"$each": assignees}}
+        // Only add while the task stays within the limit, so concurrent
+        // assigns can't push it past the limit together
+        if added := len(mergeAssignees(task.AssignedTo, assignees)) - len(task.AssignedTo); added > 0 {
+            filter[fmt.Sprintf("assigned_to.%d", models.MaxAssignees-added)] = bson.M{"$exists": false}
+        }
+    }
+
+    updated, err := updateAssignees(ctx, filter, update)
+    if errors.Is(err, errTaskNotFound) {
+        respondWithTaskUpdateError(c, staleTaskError(ctx, task, userID))
+        return
+    }
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    recordAssignmentActivity(ctx, models.ActivityTaskAssigned, task, updated, userID)
+    notifyAssignmentChange(*updated, task.AssignedTo, userID)
+    c.JSON(200, gin.H{"message": "Task assigned successfully", "assigned_to": updated.AssignedTo})
+}
+
+// UnassignTask removes the users given as user_id query parameters from a
+// task's assignees, or every assignee when none are given
+func UnassignTask(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    assignees, errs := parseAssignees(c.QueryArray("user_id"))
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    if !role.CanUpdateField("assigned_to") {
+        c.JSON(403, gin.H{"error": "Only the task creator can change assignees"})
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+
+    filter := taskAccessFilter(taskID, userID, role)
+    if version != nil {
+        filter["version"] = *version
+    }
+    update := bson.M{"$set": bson.M{"updated_at": time.Now()}}
+    if len(assignees) == 0 {
+        update["$unset"] = bson.M{"assigned_to": ""}
+    } else {
+        update["$pull"] = bson.M{"assigned_to": bson.M{"$in": assignees}}
+    }
+
+    updated, err := updateAssignees(ctx, filter, update)
+    if errors.Is(err, errTaskNotFound) {
+        respondWithTaskUpdateError(c, staleTaskError(ctx, task, userID))
+        return
+    }
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    recordAssignmentActivity(ctx, models.ActivityTaskUnassigned, task, updated, userID)
+    notifyAssignmentChange(*updated, task.AssignedTo, userID)
+    c.JSON(200, gin.H{"message": "Task unassigned successfully", "assigned_to": updated.AssignedTo})
+}
+
+// ------------------ Helper Functions ------------------
+
+// Parse user ID strings with the same rules as the task schema
+func parseAssignees(userIDs []string) ([]primitive.ObjectID, models.ValidationErrors) {
+    raw := make([]interface{}, len(userIDs))
+    for i, id := range userIDs {
+        raw[i] = id
+    }
+
+    input, errs := models.ValidateTaskInput(map[string]interface{}{"assigned_to": raw}, true)
+    if errs != nil {
+        for i := range errs {
+            errs[i].Field = "user_ids"
+        }
+        return nil, errs
+    }
+    return input.Set["assigned_to"].([]primitive.ObjectID), nil
+}
+
+// Union of two assignee lists, keeping the order of first appearance
+func mergeAssignees(current, added []primitive.ObjectID) []primitive.ObjectID {
+    merged := append([]primitive.ObjectID(nil), current...)
+    for _, id := range added {
+        if !containsObjectID(merged, id) {
+            merged = append(merged, id)
+        }
+    }
+    return merged
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+    for _, candidate := range ids {
+        if candidate == id {
+            return true
+        }
+    }
+    return false
+}
+
+// Check that every assignee is a registered user
+func validateAssigneesExist(ctx context.Context, assignees []primitive.ObjectID) (models.ValidationErrors, error) {
+    if len(assignees) == 0 {
+        return nil, nil
+    }
+
+    cursor, err := database.GetCollection(userCollection).Find(ctx,
+        bson.M{"_id": bson.M{"$in": assignees}},
+        options.Find().SetProjection(bson.M{"_id": 1}),
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    var found []struct {
+        ID primitive.ObjectID `bson:"_id"`
+    }
+    if err := cursor.All(ctx, &found); err != nil {
+        return nil, err
+    }
+
+    known := make(map[primitive.ObjectID]bool, len(found))
+    for _, u := range found {
+        known[u.ID] = true
+    }
+
+    var unknown []string
+    for _, id := range assignees {
+        if !known[id] {
+            unknown = append(unknown, id.Hex())
+        }
+    }
+    if len(unknown) > 0 {
+        return models.ValidationErrors{{
+            Field:   "assigned_to",
+            Message: "unknown users: " + strings.Join(unknown, ", "),
+        }}, nil
+    }
+    return nil, nil
+}
+
+// Apply an assignee update and return the task as it is afterwards
+func updateAssignees(ctx context.Context, filter bson.M, update bson.M) (*models.Task, error) {
+    bumpVersion(update)
+    var task models.Task
+    err := database.GetCollection(taskCollection).FindOneAndUpdate(ctx, filter, update,
+        options.FindOneAndUpdate().SetReturnDocument(options.After),
+    ).Decode(&task)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, errTaskNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &task, nil
+}
+
+// Record an assignee change in the task's history, unless nothing changed
+func recordAssignmentActivity(ctx context.Context, activityType string, before, after *models.Task, by primitive.ObjectID) {
+    changes := taskFieldChanges(before, bson.M{"assigned_to": after.AssignedTo}, nil)
+    if len(changes) == 0 {
+        return
+    }
+    entry := newActivity(before.ID, by, activityType)
+    entry.Changes = changes
+    recordActivity(ctx, entry)
+}
+
+// Tell newly added and removed assignees about the change over the websocket
+func notifyAssignmentChange(task models.Task, previous []primitive.ObjectID, by primitive.ObjectID) {
+    before := make(map[primitive.ObjectID]bool, len(previous))
+    for _, id := range previous {
+        before[id] = true
+    }
+
+    var added, removed []string
+    for _, id := range task.AssignedTo {
+        if !before[id] && id != by {
+            added = append(added, id.Hex())
+        }
+        delete(before, id)
+    }
+    for id := range before {
+        if id != by {
+            removed = append(removed, id.Hex())
+        }
+    }
+
+    payload := gin.H{
+        "task_id":     task.ID.Hex(),
+        "title":       task.Title,
+        "assigned_by": by.Hex(),
+    }
+    services.SendToUsers(added, eventTaskAssigned, payload)
+    services.SendToUsers(removed, eventTaskUnassigned, payload)
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "mime"
+    "mime/multipart"
+    "net/http"
+    "strings"
+    "time"
+    "unicode"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+
+    "backend-trackit/config"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+// multipartOverhead is how much an upload body may exceed the attachment
+// size limit to make room for the multipart framing
+const multipartOverhead = 1 << 20
+
+// sniffLength is how many bytes content type detection looks at
+const sniffLength = 512
+
+// attachmentStorage holds the contents of attachments. main sets it with
+// SetAttachmentStorage.
+var attachmentStorage services.FileStorage
+
+// SetAttachmentStorage sets where attachment contents are stored
+func SetAttachmentStorage(storage services.FileStorage) {
+    attachmentStorage = storage
+}
+
+// UploadAttachment attaches the multipart "file" field to a task. Anyone
+// who can see the task may attach files. The content type is sniffed from
+// the file itself rather than taken from the client.
+func UploadAttachment(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    if len(task.Attachments) >= models.MaxAttachmentsPerTask {
+        c.JSON(400, gin.H{"error": fmt.Sprintf("A task can have at most %d attachments", models.MaxAttachmentsPerTask)})
+        return
+    }
+
+    maxSize := config.MaxAttachmentSize()
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+    reader, err := c.Request.MultipartReader()
+    if err != nil {
+        respondWithError(c, 400, "Expected a multipart/form-data upload", err)
+        return
+    }
+    part, err := nextFilePart(reader)
+    if errors.Is(err, io.EOF) {
+        respondWithValidationErrors(c, models.ValidationErrors{{Field: "file", Message: "is required"}})
+        return
+    }
+    if err != nil {
+        respondWithUploadError(c, maxSize, err)
+        return
+    }
+    defer part.Close()
+
+    head := make([]byte, sniffLength)
+    n, err := io.ReadFull(part, head)
+    if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+        respondWithUploadError(c, maxSize, err)
+        return
+    }
+    head = head[:n]
+    if n == 0 {
+        respondWithValidationErrors(c, models.ValidationErrors{{Field: "file", Message: "cannot be empty"}})
+        return
+    }
+
+    contentType := sniffContentType(head)
+    if !isAllowedAttachmentType(contentType) {
+        c.JSON(415, gin.H{"error": "Unsupported file type", "content_type": contentType, "allowed": models.AttachmentTypes})
+        return
+    }
+
+    attachment := models.Attachment{
+        ID:          primitive.NewObjectID(),
+        Filename:    cleanAttachmentFilename(part.FileName()),
+        ContentType: contentType,
+        UploadedBy:  userID,
+        UploadedAt:  time.Now(),
+    }
+    attachment.StorageKey = attachment.ID.Hex()
+
+    // Read one byte past the limit so an oversized file can be told apart
+    // from one that is exactly at it
+    body := io.MultiReader(bytes.NewReader(head), io.LimitReader(part, maxSize-int64(n)+1))
+    size, err := attachmentStorage.Save(c.Request.Context(), attachment.StorageKey, body)
+    if err != nil {
+        respondWithUploadError(c, maxSize, err)
+        return
+    }
+    if size > maxSize {
+        discardAttachmentFile(attachment.StorageKey)
+        respondWithUploadError(c, maxSize, &http.MaxBytesError{Limit: maxSize})
+        return
+    }
+    attachment.Size = size
+
+    ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    // The attachment limit is checked again in the write itself, in case
+    // other uploads finished in the meantime
+    filter := taskAccessFilter(task.ID, userID, role)
+    filter["deleted_at"] = nil
+    filter[fmt.Sprintf("attachments.%d", models.MaxAttachmentsPerTask-1)] = bson.M{"$exists": false}
+    matched, err := updateTask(filter, bson.M{
+        "$push": bson.M{"attachments": attachment},
+        "$set":  bson.M{"updated_at": time.Now()},
+    })
+    if err != nil || matched == 0 {
+        discardAttachmentFile(attachment.StorageKey)
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to save attachment", err)
+        return
+    }
+    if matched == 0 {
+        if current, _, err := loadTaskForUser(ctx, taskID, userID); err == nil && len(current.Attachments) >= models.MaxAttachmentsPerTask {
+            c.JSON(400, gin.H{"error": fmt.Sprintf("A task can have at most %d attachments", models.MaxAttachmentsPerTask)})
+            return
+        }
+        c.JSON(404, gin.H{"error": "Task not found"})
+        return
+    }
+
+    recordAttachmentActivity(ctx, task.ID, userID, models.ActivityAttachmentAdded, nil, attachment.Filename)
+    c.JSON(201, gin.H{"message": "Attachment uploaded successfully", "attachment": attachment})
+}
+
+// GetTaskAttachments lists a task's attachments in upload order
+func GetTaskAttachments(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, _, err := loadTaskForUser(ctx, taskID, currentUserID(c))
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    attachments := task.Attachments
+    if attachments == nil {
+        attachments = []models.Attachment{}
+    }
+    c.JSON(200, gin.H{"attachments": attachments})
+}
+
+// DownloadAttachment streams an attachment's contents. Range and
+// conditional requests are supported, so large downloads can be resumed.
+func DownloadAttachment(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    attachmentID, _ := primitive.ObjectIDFromHex(c.Param("attachmentId"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, _, err := loadTaskForUser(ctx, taskID, currentUserID(c))
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    attachment := findAttachment(task, attachmentID)
+    if attachment == nil {
+        c.JSON(404, gin.H{"error": "Attachment not found"})
+        return
+    }
+
+    // The download can outlast the lookup timeout; it ends with the request
+    file, err := attachmentStorage.Open(c.Request.Context(), attachment.StorageKey)
+    if errors.Is(err, services.ErrFileNotFound) {
+        c.JSON(404, gin.H{"error": "Attachment not found"})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to open attachment", err)
+        return
+    }
+    defer file.Close()
+
+    c.Header("Content-Type", attachment.ContentType)
+    c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+    c.Header("X-Content-Type-Options", "nosniff")
+    c.Header("ETag", `"`+attachment.ID.Hex()+`"`)
+    http.ServeContent(c.Writer, c.Request, attachment.Filename, attachment.UploadedAt, file)
+}
+
+// DeleteAttachment removes an attachment. Only its uploader or the task
+// creator may delete it.
+func DeleteAttachment(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    attachmentID, _ := primitive.ObjectIDFromHex(c.Param("attachmentId"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    attachment := findAttachment(task, attachmentID)
+    if attachment == nil {
+        c.JSON(404, gin.H{"error": "Attachment not found"})
+        return
+    }
+    if attachment.UploadedBy != userID && role != services.TaskRoleCreator {
+        c.JSON(403, gin.H{"error": "Only the uploader or the task creator can delete this attachment"})
+        return
+    }
+
+    filter := taskAccessFilter(task.ID, userID, role)
+    filter["deleted_at"] = nil
+    filter["attachments.id"] = attachment.ID
+    matched, err := updateTask(filter, bson.M{
+        "$pull": bson.M{"attachments": bson.M{"id": attachment.ID}},
+        "$set":  bson.M{"updated_at": time.Now()},
+    })
+    if err != nil {
+        respondWithError(c, 500, "Failed to delete attachment", err)
+        return
+    }
+    if matched == 0 {
+        c.JSON(404, gin.H{"error": "Attachment not found"})
+        return
+    }
+
+    // The task no longer refers to the file, so a failure here only leaves
+    // an orphaned file behind
+    if err := attachmentStorage.Delete(ctx, attachment.StorageKey); err != nil {
+        log.Printf("Failed to delete attachment file %s: %v", attachment.StorageKey, err)
+    }
+
+    recordAttachmentActivity(ctx, task.ID, userID, models.ActivityAttachmentDeleted, attachment.Filename, nil)
+    c.JSON(200, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// ------------------ Helper Functions ------------------
+
+// Advance to the upload's "file" part, skipping any other form fields
+func nextFilePart(reader *multipart.Reader) (*multipart.Part, error) {
+    for {
+        part, err := reader.NextPart()
+        if err != nil {
+            return nil, err
+        }
+        if part.FormName() == "file" && part.FileName() != "" {
+            return part, nil
+        }
+        part.Close()
+    }
+}
+
+// Respond to a failed upload, telling an oversized file apart from other
+// failures
+func respondWithUploadError(c *gin.Context, maxSize int64, err error) {
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        c.JSON(413, gin.H{"error": fmt.Sprintf("Attachments can be at most %d MB", maxSize>>20)})
+        return
+    }
+    respondWithError(c, 400, "Failed to read upload", err)
+}
+
+// sniffContentType detects a file's media type from its first bytes,
+// without parameters such as the charset
+func sniffContentType(head []byte) string {
+    detected := http.DetectContentType(head)
+    mediaType, _, err := mime.ParseMediaType(detected)
+    if err != nil {
+        return detected
+    }
+    return mediaType
+}
+
+func isAllowedAttachmentType(contentType string) bool {
+    for _, allowed := range models.AttachmentTypes {
+        if contentType == allowed {
+            return true
+        }
+    }
+    return false
+}
+
+// cleanAttachmentFilename keeps the base name of an uploaded file, without
+// control characters and within the length limit
+func cleanAttachmentFilename(name string) string {
+    if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+        name = name[i+1:]
+    }
+    name = strings.Map(func(r rune) rune {
+        if unicode.IsControl(r) {
+            return -1
+        }
+        return r
+    }, name)
+    name = strings.TrimSpace(name)
+
+    if runes := []rune(name); len(runes) > models.AttachmentFilenameMaxLength {
+        name = string(runes[:models.AttachmentFilenameMaxLength])
+    }
+    if name == "" || name == "." || name == ".." {
+        return "attachment"
+    }
+    return name
+}
+
+func findAttachment(task *models.Task, attachmentID primitive.ObjectID) *models.Attachment {
+    for i := range task.Attachments {
+        if task.Attachments[i].ID == attachmentID {
+            return &task.Attachments[i]
+        }
+    }
+    return nil
+}
+
+// Remove a stored file that no task refers to
+func discardAttachmentFile(key string) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    if err := attachmentStorage.Delete(ctx, key); err != nil {
+        log.Printf("Failed to delete attachment file %s: %v", key, err)
+    }
+}
+
+// Remove the stored files of the given attachments
+func deleteAttachmentFiles(ctx context.Context, attachments []models.Attachment) error {
+    for _, attachment := range attachments {
+        if err := attachmentStorage.Delete(ctx, attachment.StorageKey); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Record an attachment being added or removed in the task's history
+func recordAttachmentActivity(ctx context.Context, taskID, userID primitive.ObjectID, activityType string, from, to interface{}) {
+    entry := newActivity(taskID, userID, activityType)
+    entry.Changes = []models.FieldChange{{Field: "attachments", From: from, To: to}}
+    recordActivity(ctx, entry)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+const (
+    eventTaskMoved      = "task_moved"
+    eventBoardRebalance = "board_rebalanced"
+)
+
+// maxRankLength is how long ranks in a column may grow before
+// RebalanceBoardRanks spreads them out again
+const maxRankLength = 10
+
+// errRanksOutOfOrder is returned when a move's neighbours are not in the
+// order the client assumed
+var errRanksOutOfOrder = errors.New("neighbours are out of order")
+
+// MoveTask places a task on the board: into the column of the given status,
+// if any, between two neighbours. after_id names the task the moved task
+// should follow and before_id the one it should precede; either may be left
+// out at the top or bottom of a column, and leaving out both moves the task
+// to the bottom. Other viewers of the task are told about the move.
+func MoveTask(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        Status   *string `json:"status"`
+        AfterID  *string `json:"after_id"`
+        BeforeID *string `json:"before_id"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    fields := map[string]interface{}{}
+    if input.Status != nil {
+        fields["status"] = *input.Status
+    }
+    validated, errs := models.ValidateTaskInput(fields, true)
+    afterID, afterErrs := parseNeighbourID("after_id", input.AfterID)
+    beforeID, beforeErrs := parseNeighbourID("before_id", input.BeforeID)
+    errs = append(append(errs, afterErrs...), beforeErrs...)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+
+    status := task.Status
+    if s, ok := validated.Set["status"].(string); ok {
+        status = s
+    }
+
+    rank, err := rankForMove(ctx, task, status, afterID, beforeID, userID)
+    if errors.Is(err, errRanksOutOfOrder) {
+        c.JSON(409, gin.H{"error": "The board has changed; after_id and before_id are no longer adjacent in that order"})
+        return
+    }
+    if err != nil {
+        respondWithTaskUpdateError(c, err)
+        return
+    }
+
+    change := newTaskChange(task, role, userID, validated)
+    change.Set["rank"] = rank
+    change.Version = version
+    if err := applyTaskChange(ctx, change); err != nil {
+        respondWithTaskUpdateError(c, err)
+        return
+    }
+
+    notifyTaskAudience(task, eventTaskMoved, gin.H{
+        "id":        task.ID,
+        "status":    status,
+        "rank":      rank,
+        "after_id":  afterID,
+        "before_id": beforeID,
+        "by":        userID,
+    }, userID)
+    respondWithUpdatedTask(c, task.ID, userID, "Task moved successfully")
+}
+
+// RebalanceBoardRanks gives every column whose ranks have grown too long,
+// or that has unranked tasks, short evenly spaced ranks in its current
+// order. Unranked tasks go to the bottom, oldest first. It returns the
+// number of tasks whose rank changed.
+func RebalanceBoardRanks(ctx context.Context) (int64, error) {
+    collection := database.GetCollection(taskCollection)
+
+    var total int64
+    for _, status := range models.TaskStatuses {
+        needed, err := collection.CountDocuments(ctx, bson.M{
+            "status":     status,
+            "deleted_at": nil,
+            "$or": []bson.M{
+                {"rank": bson.M{"$exists": false}},
+                {"rank": bson.M{"$regex": fmt.Sprintf("^.{%d,}", maxRankLength+1)}},
+            },
+        }, options.Count().SetLimit(1))
+        if err != nil {
+            return total, err
+        }
+        if needed == 0 {
+            continue
+        }
+
+        changed, err := rebalanceColumn(ctx, status)
+        total += changed
+        if err != nil {
+            return total, err
+        }
+    }
+    return total, nil
+}
+
+// ------------------ Helper Functions ------------------
+
+func parseNeighbourID(field string, raw *string) (*primitive.ObjectID, models.ValidationErrors) {
+    if raw == nil {
+        return nil, nil
+    }
+    id, err := primitive.ObjectIDFromHex(*raw)
+    if err != nil {
+        return nil, models.ValidationErrors{{Field: field, Message: "must be a valid ID"}}
+    }
+    return &id, nil
+}
+
+// rankForMove works out the rank that puts the task between its new
+// neighbours in the status column. When the neighbours have no room
+// between them, or no ranks yet, the column is rebalanced and the rank
+// worked out again.
+func rankForMove(ctx context.Context, task *models.Task, status string, afterID, beforeID *primitive.ObjectID, userID primitive.ObjectID) (string, error) {
+    for attempt := 0; ; attempt++ {
+        after, err := loadNeighbour(ctx, "after_id", afterID, task, status, userID)
+        if err != nil {
+            return "", err
+        }
+        before, err := loadNeighbour(ctx, "before_id", beforeID, task, status, userID)
+        if err != nil {
+            return "", err
+        }
+
+        var lower, upper string
+        unranked := false
+        if after != nil {
+            lower, unranked = after.Rank, after.Rank == ""
+        } else if before == nil {
+            if lower, err = columnEndRank(ctx, status, task.ID); err != nil {
+                return "", err
+            }
+        }
+        if before != nil {
+            upper, unranked = before.Rank, unranked || before.Rank == ""
+        }
+
+        if !unranked {
+            rank, err := services.RankBetween(lower, upper)
+            if err == nil {
+                return rank, nil
+            }
+            if lower >= upper && services.IsValidRank(lower) && services.IsValidRank(upper) && lower != upper {
+                // Well-formed ranks the wrong way round: the client's view
+                // of the board is out of date
+                return "", errRanksOutOfOrder
+            }
+        }
+        if attempt > 0 {
+            return "", errRanksOutOfOrder
+        }
+        if _, err := rebalanceColumn(ctx, status); err != nil {
+            return "", err
+        }
+    }
+}
+
+// Load a move's neighbour, which must be a task the user can see in the
+// target column. A nil id means there is no neighbour on that side.
+func loadNeighbour(ctx context.Context, field string, id *primitive.ObjectID, task *models.Task, status string, userID primitive.ObjectID) (*models.Task, error) {
+    if id == nil {
+        return nil, nil
+    }
+    if *id == task.ID {
+        return nil, validationFailed(models.ValidationErrors{{Field: field, Message: "cannot be the task being moved"}})
+    }
+    neighbour, _, err := loadTaskForUser(ctx, *id, userID)
+    if errors.Is(err, errTaskNotFound) {
+        return nil, validationFailed(models.ValidationErrors{{Field: field, Message: "unknown task"}})
+    }
+    if err != nil {
+        return nil, err
+    }
+    if neighbour.Status != status {
+        return nil, &taskUpdateError{409, gin.H{
+            "error": fmt.Sprintf("%s is no longer in the %s column", field, status),
+            "task":  neighbour,
+        }}
+    }
+    return neighbour, nil
+}
+
+// columnEndRank returns the highest rank in a status column, leaving out
+// the given task, or "" for an empty column
+func columnEndRank(ctx context.Context, status string, exclude primitive.ObjectID) (string, error) {
+    var last struct {
+        Rank string `bson:"rank"`
+    }
+    err := database.GetCollection(taskCollection).FindOne(ctx,
+        bson.M{"status": status, "deleted_at": nil, "rank": bson.M{"$exists": true}, "_id": bson.M{"$ne": exclude}},
+        options.FindOne().SetSort(bson.D{{Key: "rank", Value: -1}}).SetProjection(bson.M{"rank": 1}),
+    ).Decode(&last)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return "", nil
+    }
+    return last.Rank, err
+}
+
+// rankAtColumnEnd returns a rank that puts a task at the bottom of a column
+func rankAtColumnEnd(ctx context.Context, status string, exclude primitive.ObjectID) (string, error) {
+    last, err := columnEndRank(ctx, status, exclude)
+    if err != nil {
+        return "", err
+    }
+    if !services.IsValidRank(last) {
+        // A malformed rank is left for the rebalance to fix
+        last = ""
+    }
+    return services.RankBetween(last, "")
+}
+
+// rebalanceColumn rewrites the ranks of a status column as an evenly spaced
+// sequence, keeping the current order. Each write only applies if the task
+// still has the rank it was read with, so a concurrent move is not undone.
+func rebalanceColumn(ctx context.Context, status string) (int64, error) {
+    collection := database.GetCollection(taskCollection)
+    projection := bson.M{"_id": 1, "rank": 1}
+
+    var ranked, unranked []struct {
+        ID   primitive.ObjectID `bson:"_id"`
+        Rank *string            `bson:"rank"`
+    }
+    cursor, err := collection.Find(ctx,
+        bson.M{"status": status, "deleted_at": nil, "rank": bson.M{"$exists": true}},
+        options.Find().SetSort(bson.D{{Key: "rank", Value: 1}, {Key: "_id", Value: 1}}).SetProjection(projection),
+    )
+    if err != nil {
+        return 0, err
+    }
+    if err := cursor.All(ctx, &ranked); err != nil {
+        return 0, err
+    }
+    cursor, err = collection.Find(ctx,
+        bson.M{"status": status, "deleted_at": nil, "rank": bson.M{"$exists": false}},
+        options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).SetProjection(projection),
+    )
+    if err != nil {
+        return 0, err
+    }
+    if err := cursor.All(ctx, &unranked); err != nil {
+        return 0, err
+    }
+
+    tasks := append(ranked, unranked...)
+    ranks := services.RankSequence(len(tasks))
+    var writes []mongo.WriteModel
+    for i, t := range tasks {
+        filter := bson.M{"_id": t.ID, "rank": bson.M{"$exists": false}}
+        if t.Rank != nil {
+            if *t.Rank == ranks[i] {
+                continue
+            }
+            filter["rank"] = *t.Rank
+        }
+        // Ranks are board bookkeeping, so a rebalance leaves task versions
+        // and ETags alone
+        writes = append(writes, mongo.NewUpdateOneModel().
+            SetFilter(filter).
+            SetUpdate(bson.M{"$set": bson.M{"rank": ranks[i]}}))
+    }
+    if len(writes) == 0 {
+        return 0, nil
+    }
+
+    result, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+    if result == nil {
+        return 0, err
+    }
+    if result.ModifiedCount > 0 {
+        services.BroadcastMessage(eventBoardRebalance, gin.H{"status": status})
+    }
+    return result.ModifiedCount, err
+}
+
+// Send a task event to everyone who can see the task except the actor
+func notifyTaskAudience(task *models.Task, event string, data interface{}, by primitive.ObjectID) {
+    var recipients []string
+    for _, id := range taskAudience(task) {
+        if id != by {
+            recipients = append(recipients, id.Hex())
+        }
+    }
+    services.SendToUsers(recipients, event, data)
+}
@@ -0,0 +1,561 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+// Limits on a single bulk request
+const (
+    maxBulkOperations = 50
+    maxBulkItems      = 500
+)
+
+// Operations accepted by POST /api/tasks/bulk
+const (
+    bulkUpdate     = "update"
+    bulkStatus     = "status"
+    bulkAddTags    = "add_tags"
+    bulkRemoveTags = "remove_tags"
+    bulkAssign     = "assign"
+    bulkDelete     = "delete"
+)
+
+// bulkOperation applies one action to a list of tasks. Which of the other
+// fields are read depends on Op.
+type bulkOperation struct {
+    Op       string                 `json:"op"`
+    TaskIDs  []string               `json:"task_ids"`
+    Fields   map[string]interface{} `json:"fields"`   // update
+    Status   string                 `json:"status"`   // status
+    Comment  string                 `json:"comment"`  // status
+    Tags     []string               `json:"tags"`     // add_tags, remove_tags
+    UserIDs  []string               `json:"user_ids"` // assign
+    Replace  bool                   `json:"replace"`  // assign
+    Children string                 `json:"children"` // delete
+}
+
+// BulkItemResult is the outcome of one operation on one task. Status is
+// the HTTP status the same change would have had on its own.
+type BulkItemResult struct {
+    Operation int    `json:"operation"`
+    TaskID    string `json:"task_id"`
+    Status    int    `json:"status"`
+    Error     string `json:"error,omitempty"`
+    Details   gin.H  `json:"details,omitempty"`
+}
+
+// bulkPlan is a validated operation
+type bulkPlan struct {
+    bulkOperation
+    input     *models.TaskInput    // update, status
+    tags      []string             // add_tags, remove_tags
+    assignees []primitive.ObjectID // assign
+}
+
+// bulkWriteIDField holds the ID of the last bulk write applied to a task,
+// which is how runBulkWrites tells which writes of a batch landed
+const bulkWriteIDField = "bulk_write_id"
+
+// bulkWrite is a queued write and the follow-up work to run once it lands
+type bulkWrite struct {
+    id     primitive.ObjectID
+    result int
+    taskID primitive.ObjectID
+    filter bson.M
+    update bson.M
+    finish func(ctx context.Context)
+}
+
+// BulkTasks applies a list of operations to many tasks in one request.
+// Operations run in order and each sees the result of the ones before it.
+// Every task is authorized and validated on its own; the writes that pass
+// are sent in a single ordered BulkWrite, and the response reports the
+// outcome of every operation on every task.
+func BulkTasks(c *gin.Context) {
+    userID := currentUserID(c)
+
+    var input struct {
+        Operations []bulkOperation `json:"operations" binding:"required,min=1"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+    if len(input.Operations) > maxBulkOperations {
+        c.JSON(400, gin.H{"error": fmt.Sprintf("at most %d operations are allowed per request", maxBulkOperations)})
+        return
+    }
+    items := 0
+    for _, op := range input.Operations {
+        items += len(op.TaskIDs)
+    }
+    if items > maxBulkItems {
+        c.JSON(400, gin.H{"error": fmt.Sprintf("at most %d task operations are allowed per request", maxBulkItems)})
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    plans := make([]*bulkPlan, len(input.Operations))
+    var errs models.ValidationErrors
+    for i, op := range input.Operations {
+        plan, opErrs, err := planBulkOperation(ctx, op)
+        if err != nil {
+            respondWithError(c, 500, "Failed to validate operations", err)
+            return
+        }
+        for _, e := range opErrs {
+            errs = append(errs, models.FieldError{Field: fmt.Sprintf("operations[%d].%s", i, e.Field), Message: e.Message})
+        }
+        plans[i] = plan
+    }
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    tasks, err := loadBulkTasks(ctx, plans, userID)
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch tasks", err)
+        return
+    }
+
+    results := []BulkItemResult{}
+    var writes []bulkWrite
+    lastWrite := make(map[primitive.ObjectID]primitive.ObjectID)
+    columnEnds := make(map[string]string)
+    for i, plan := range plans {
+        for _, hex := range plan.TaskIDs {
+            results = append(results, BulkItemResult{Operation: i, TaskID: hex})
+            result := &results[len(results)-1]
+
+            taskID, err := primitive.ObjectIDFromHex(hex)
+            if err != nil {
+                result.Status, result.Error = 400, "Invalid task ID"
+                continue
+            }
+            task, ok := tasks[taskID]
+            if !ok {
+                result.Status, result.Error = 404, "Task not found"
+                continue
+            }
+
+            write, next, err := plan.prepare(ctx, task, userID, columnEnds)
+            if err != nil {
+                setBulkError(result, err)
+                continue
+            }
+            write.result = len(results) - 1
+            previous, chained := lastWrite[taskID]
+            stampBulkWrite(write, previous, chained)
+            lastWrite[taskID] = write.id
+            writes = append(writes, *write)
+
+            if next == nil {
+                delete(tasks, taskID)
+            } else {
+                tasks[taskID] = next
+            }
+        }
+    }
+
+    runBulkWrites(ctx, writes, results)
+
+    succeeded := 0
+    for _, result := range results {
+        if result.Status == 200 {
+            succeeded++
+        }
+    }
+    c.JSON(200, gin.H{"results": results, "succeeded": succeeded, "failed": len(results) - succeeded})
+}
+
+// ------------------ Helper Functions ------------------
+
+// Validate an operation's arguments, which apply to every task it names
+func planBulkOperation(ctx context.Context, op bulkOperation) (*bulkPlan, models.ValidationErrors, error) {
+    plan := &bulkPlan{bulkOperation: op}
+    if len(op.TaskIDs) == 0 {
+        return nil, models.ValidationErrors{{Field: "task_ids", Message: "is required"}}, nil
+    }
+
+    switch op.Op {
+    case bulkUpdate:
+        if len(op.Fields) == 0 {
+            return nil, models.ValidationErrors{{Field: "fields", Message: "is required"}}, nil
+        }
+        input, errs := models.ValidateTaskInput(op.Fields, true)
+        for i := range errs {
+            errs[i].Field = "fields." + errs[i].Field
+        }
+        plan.input = input
+        return plan, errs, nil
+
+    case bulkStatus:
+        input, errs := models.ValidateTaskInput(map[string]interface{}{"status": op.Status}, true)
+        plan.input = input
+        return plan, errs, nil
+
+    case bulkAddTags, bulkRemoveTags:
+        if len(op.Tags) == 0 {
+            return nil, models.ValidationErrors{{Field: "tags", Message: "is required"}}, nil
+        }
+        raw := make([]interface{}, len(op.Tags))
+        for i, tag := range op.Tags {
+            raw[i] = tag
+        }
+        input, errs := models.ValidateTaskInput(map[string]interface{}{"tags": raw}, true)
+        if errs != nil {
+            return nil, errs, nil
+        }
+        plan.tags = input.Set["tags"].([]string)
+        return plan, nil, nil
+
+    case bulkAssign:
+        if len(op.UserIDs) == 0 && !op.Replace {
+            return nil, models.ValidationErrors{{Field: "user_ids", Message: "is required"}}, nil
+        }
+        assignees, errs := parseAssignees(op.UserIDs)
+        if errs != nil {
+            return nil, errs, nil
+        }
+        errs, err := validateAssigneesExist(ctx, assignees)
+        for i := range errs {
+            errs[i].Field = "user_ids"
+        }
+        plan.assignees = assignees
+        return plan, errs, err
+
+    case bulkDelete:
+        if plan.Children == "" {
+            plan.Children = childrenOrphan
+        }
+        if !isValidChildrenMode(plan.Children) {
+            return nil, models.ValidationErrors{{Field: "children", Message: "must be one of: orphan, reparent, delete"}}, nil
+        }
+        return plan, nil, nil
+    }
+
+    return nil, models.ValidationErrors{{
+        Field:   "op",
+        Message: "must be one of: update, status, add_tags, remove_tags, assign, delete",
+    }}, nil
+}
+
+// Load every live task named by the plans that the user can see
+func loadBulkTasks(ctx context.Context, plans []*bulkPlan, userID primitive.ObjectID) (map[primitive.ObjectID]*models.Task, error) {
+    var ids []primitive.ObjectID
+    for _, plan := range plans {
+        for _, hex := range plan.TaskIDs {
+            if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+                ids = append(ids, id)
+            }
+        }
+    }
+
+    cursor, err := database.GetCollection(taskCollection).Find(ctx, bson.M{
+        "$and": []bson.M{{"_id": bson.M{"$in": ids}}, visibleTasksFilter(userID)},
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    var found []models.Task
+    if err := cursor.All(ctx, &found); err != nil {
+        return nil, err
+    }
+
+    tasks := make(map[primitive.ObjectID]*models.Task, len(found))
+    for i := range found {
+        tasks[found[i].ID] = &found[i]
+    }
+    return tasks, nil
+}
+
+// prepare checks the operation against one task and returns the write for
+// it along with the task as it will be afterwards (nil once deleted). The
+// write is conditioned on the task's current version, so it only lands on
+// the state it was checked against. columnEnds chains the ranks of tasks
+// moved to the same column in the batch, so they keep the request's order.
+func (plan *bulkPlan) prepare(ctx context.Context, task *models.Task, userID primitive.ObjectID, columnEnds map[string]string) (*bulkWrite, *models.Task, error) {
+    role := services.RoleForTask(*task, userID)
+    version := task.Version
+
+    if plan.Op == bulkDelete {
+        if !role.CanDelete() {
+            return nil, nil, &taskUpdateError{403, gin.H{"error": "Only the task creator can delete this task"}}
+        }
+
+        now := time.Now()
+        filter := taskAccessFilter(task.ID, userID, role)
+        filter["version"] = version
+        filter["deleted_at"] = nil
+        update := trashUpdate(userID, now)
+        bumpVersion(update)
+
+        mode := plan.Children
+        return &bulkWrite{
+            taskID: task.ID,
+            filter: filter,
+            update: update,
+            finish: func(ctx context.Context) {
+                recordActivity(ctx, newActivity(task.ID, userID, models.ActivityTaskDeleted))
+                if err := detachSubtasks(ctx, task, mode, userID, now); err != nil {
+                    log.Printf("Failed to detach subtasks of %s: %v", task.ID.Hex(), err)
+                }
+                refreshSubtaskRollups(ctx, task.ParentID)
+            },
+        }, nil, nil
+    }
+
+    change, err := plan.change(task, role, userID)
+    if err != nil {
+        return nil, nil, err
+    }
+    change.Version = &version
+    change.ColumnEnds = columnEnds
+
+    prepared, err := prepareTaskChange(ctx, change)
+    if err != nil {
+        return nil, nil, err
+    }
+    bumpVersion(prepared.update)
+
+    next, err := taskAfterChange(task, change.Set, change.Unset)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return &bulkWrite{
+        taskID: task.ID,
+        filter: prepared.filter,
+        update: prepared.update,
+        finish: prepared.finish,
+    }, next, nil
+}
+
+// change builds the task change an operation makes to one task
+func (plan *bulkPlan) change(task *models.Task, role services.TaskRole, userID primitive.ObjectID) (*taskChange, error) {
+    switch plan.Op {
+    case bulkUpdate:
+        return newTaskChange(task, role, userID, plan.input), nil
+
+    case bulkStatus:
+        change := newTaskChange(task, role, userID, plan.input)
+        change.Comment = plan.Comment
+        return change, nil
+
+    case bulkAddTags, bulkRemoveTags:
+        tags := models.NormalizeTags(append(append([]string(nil), task.Tags...), plan.tags...))
+        if plan.Op == bulkRemoveTags {
+            removed := make(map[string]bool, len(plan.tags))
+            for _, tag := range plan.tags {
+                removed[tag] = true
+            }
+            tags = []string{}
+            for _, tag := range task.Tags {
+                if !removed[tag] {
+                    tags = append(tags, tag)
+                }
+            }
+        }
+        if len(tags) > models.MaxTags {
+            return nil, validationFailed(models.ValidationErrors{{
+                Field:   "tags",
+                Message: fmt.Sprintf("must contain at most %d tags", models.MaxTags),
+            }})
+        }
+        change := &taskChange{Task: task, Role: role, UserID: userID, Set: bson.M{"tags": tags}, Unset: bson.M{}}
+        change.Push, change.Pull = arrayDeltas(task, change.Set)
+        return change, nil
+
+    case bulkAssign:
+        assignees := plan.assignees
+        if !plan.Replace {
+            assignees = mergeAssignees(task.AssignedTo, plan.assignees)
+        }
+        if len(assignees) > models.MaxAssignees {
+            return nil, validationFailed(models.ValidationErrors{{
+                Field:   "user_ids",
+                Message: fmt.Sprintf("a task can have at most %d assignees", models.MaxAssignees),
+            }})
+        }
+        return &taskChange{Task: task, Role: role, UserID: userID, Set: bson.M{"assigned_to": assignees}, Unset: bson.M{}}, nil
+    }
+    return nil, fmt.Errorf("unknown bulk operation %q", plan.Op)
+}
+
+// taskAfterChange returns a copy of the task with the change applied
+func taskAfterChange(task *models.Task, set, unset bson.M) (*models.Task, error) {
+    doc, err := toBSONDocument(task)
+    if err != nil {
+        return nil, err
+    }
+    for field := range unset {
+        delete(doc, field)
+    }
+    for field, value := range set {
+        doc[field] = value
+    }
+
+    data, err := bson.Marshal(doc)
+    if err != nil {
+        return nil, err
+    }
+    var next models.Task
+    if err := bson.Unmarshal(data, &next); err != nil {
+        return nil, err
+    }
+    next.Version = task.Version + 1
+    return &next, nil
+}
+
+// stampBulkWrite gives a write an ID of its own and stores it on the task
+// with the write. A write that follows another one to the same task in the
+// batch only applies on top of that write, so each task's writes land in
+// order up to the first that doesn't.
+func stampBulkWrite(w *bulkWrite, previous primitive.ObjectID, chained bool) {
+    w.id = primitive.NewObjectID()
+    set, ok := w.update["$set"].(bson.M)
+    if !ok {
+        set = bson.M{}
+        w.update["$set"] = set
+    }
+    set[bulkWriteIDField] = w.id
+    if chained {
+        w.filter[bulkWriteIDField] = previous
+    }
+}
+
+// Send the queued writes in one ordered BulkWrite and record the outcome of
+// each. The overall result can't tell which writes matched, so the outcome
+// is read back from the write IDs the tasks hold afterwards: a task's writes
+// up to the one whose ID it holds landed, the rest did not. A write that
+// didn't land because the task changed in the meantime, or an earlier write
+// to it failed, is reported as a conflict. Follow-up work only runs for
+// writes that landed.
+func runBulkWrites(ctx context.Context, writes []bulkWrite, results []BulkItemResult) {
+    if len(writes) == 0 {
+        return
+    }
+
+    writeModels := make([]mongo.WriteModel, len(writes))
+    for i, w := range writes {
+        writeModels[i] = mongo.NewUpdateOneModel().SetFilter(w.filter).SetUpdate(w.update)
+    }
+
+    collection := database.GetCollection(taskCollection)
+    _, err := collection.BulkWrite(ctx, writeModels, options.BulkWrite().SetOrdered(true))
+
+    failedAt := -1
+    var bulkErr mongo.BulkWriteException
+    if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+        failedAt = bulkErr.WriteErrors[0].Index
+        log.Printf("Bulk task write failed at %d: %v", failedAt, err)
+    } else if err != nil {
+        log.Printf("Bulk task write failed: %v", err)
+    }
+
+    stored, verr := storedBulkWriteIDs(ctx, writes)
+    if verr != nil {
+        log.Printf("Failed to verify bulk task writes: %v", verr)
+        for _, w := range writes {
+            results[w.result].Status, results[w.result].Error = 500, "Outcome unknown, reload the task"
+        }
+        return
+    }
+
+    landed := make([]bool, len(writes))
+    pending := make(map[primitive.ObjectID][]int)
+    for i, w := range writes {
+        pending[w.taskID] = append(pending[w.taskID], i)
+        if stored[w.taskID] == w.id {
+            for _, j := range pending[w.taskID] {
+                landed[j] = true
+            }
+        }
+    }
+
+    for i, w := range writes {
+        item := &results[w.result]
+        switch {
+        case landed[i]:
+            item.Status = 200
+            w.finish(ctx)
+        case (failedAt >= 0 && i >= failedAt) || (err != nil && failedAt < 0):
+            // An ordered BulkWrite stops at the first failure
+            item.Status, item.Error = 500, "Failed to write task"
+        default:
+            item.Status, item.Error = 409, "Task was modified by someone else"
+        }
+    }
+}
+
+// Fetch the ID of the last bulk write stored on every task the writes touch
+func storedBulkWriteIDs(ctx context.Context, writes []bulkWrite) (map[primitive.ObjectID]primitive.ObjectID, error) {
+    ids := make([]primitive.ObjectID, len(writes))
+    for i, w := range writes {
+        ids[i] = w.taskID
+    }
+
+    cursor, err := database.GetCollection(taskCollection).Find(ctx,
+        bson.M{"_id": bson.M{"$in": ids}},
+        options.Find().SetProjection(bson.M{bulkWriteIDField: 1}),
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    var found []struct {
+        ID      primitive.ObjectID `bson:"_id"`
+        WriteID primitive.ObjectID `bson:"bulk_write_id"`
+    }
+    if err := cursor.All(ctx, &found); err != nil {
+        return nil, err
+    }
+
+    stored := make(map[primitive.ObjectID]primitive.ObjectID, len(found))
+    for _, t := range found {
+        stored[t.ID] = t.WriteID
+    }
+    return stored, nil
+}
+
+// Fill in a failed item from an error returned while preparing it
+func setBulkError(result *BulkItemResult, err error) {
+    var updateErr *taskUpdateError
+    switch {
+    case errors.As(err, &updateErr):
+        result.Status = updateErr.Status
+        result.Error, _ = updateErr.Body["error"].(string)
+        details := gin.H{}
+        for key, value := range updateErr.Body {
+            if key != "error" {
+                details[key] = value
+            }
+        }
+        if len(details) > 0 {
+            result.Details = details
+        }
+    case errors.Is(err, errTaskNotFound):
+        result.Status, result.Error = 404, "Task not found"
+    default:
+        log.Printf("Failed to prepare bulk change for task %s: %v", result.TaskID, err)
+        result.Status, result.Error = 500, "Failed to update task"
+    }
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+    "context"
+    "testing"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+    "backend-trackit/database"
+)
+
+// The outcome of each write comes from the write ID the task holds after the
+// BulkWrite, not from the overall matched count
+func TestRunBulkWritesOutcome(t *testing.T) {
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+    mt.Run("outcome", func(mt *mtest.T) {
+        database.DB = mt.DB
+        taskA, taskB, taskC := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+        finished := make([]bool, 4)
+        var writes []bulkWrite
+        lastWrite := make(map[primitive.ObjectID]primitive.ObjectID)
+        for i, taskID := range []primitive.ObjectID{taskA, taskA, taskB, taskC} {
+            i := i
+            w := &bulkWrite{
+                result: i,
+                taskID: taskID,
+                filter: bson.M{"_id": taskID, "version": int64(1)},
+                update: bson.M{"$set": bson.M{"title": "x"}},
+                finish: func(context.Context) { finished[i] = true },
+            }
+            previous, chained := lastWrite[taskID]
+            stampBulkWrite(w, previous, chained)
+            lastWrite[taskID] = w.id
+            writes = append(writes, *w)
+        }
+        if writes[1].filter[bulkWriteIDField] != writes[0].id {
+            mt.Fatalf("second write to a task is not chained to the first")
+        }
+
+        // The first write to A landed and the second didn't; B was edited by
+        // someone else in the meantime; C landed
+        mt.AddMockResponses(
+            mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}),
+            mtest.CreateCursorResponse(0, "trackit."+taskCollection, mtest.FirstBatch,
+                bson.D{{Key: "_id", Value: taskA}, {Key: bulkWriteIDField, Value: writes[0].id}},
+                bson.D{{Key: "_id", Value: taskB}},
+                bson.D{{Key: "_id", Value: taskC}, {Key: bulkWriteIDField, Value: writes[3].id}},
+            ),
+        )
+
+        results := make([]BulkItemResult, len(writes))
+        runBulkWrites(context.Background(), writes, results)
+
+        want := []int{200, 409, 409, 200}
+        for i, result := range results {
+            if result.Status != want[i] {
+                mt.Errorf("write %d: status = %d, want %d", i, result.Status, want[i])
+            }
+            if finished[i] != (want[i] == 200) {
+                mt.Errorf("write %d: finish ran = %v, want %v", i, finished[i], want[i] == 200)
+            }
+        }
+    })
+}
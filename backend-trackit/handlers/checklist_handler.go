@@ -0,0 +1,413 @@
+package handlers
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+// AddChecklistItem appends an item to a task's checklist, or inserts it at
+// position when one is given
+func AddChecklistItem(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        Text     string `json:"text"`
+        Position *int   `json:"position"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+    text, errs := validateChecklistText(input.Text)
+    if input.Position != nil && *input.Position < 0 {
+        errs = append(errs, models.FieldError{Field: "position", Message: "cannot be negative"})
+    }
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, version, ok := loadTaskForChecklistEdit(c, ctx, taskID, userID)
+    if !ok {
+        return
+    }
+
+    item := models.ChecklistItem{ID: primitive.NewObjectID(), Text: text, CreatedAt: time.Now()}
+    push := bson.M{"$each": []models.ChecklistItem{item}}
+    if input.Position != nil {
+        push["$position"] = *input.Position
+    }
+
+    filter := checklistFilter(task, userID, role, version)
+    // The item limit is checked in the same write as the push
+    filter[fmt.Sprintf("checklist.%d", models.MaxChecklistItems-1)] = bson.M{"$exists": false}
+    matched, err := updateTask(filter, bson.M{
+        "$push": bson.M{"checklist": push},
+        "$set":  bson.M{"updated_at": time.Now()},
+    })
+    if err != nil {
+        respondWithError(c, 500, "Failed to add checklist item", err)
+        return
+    }
+    if matched == 0 {
+        if current, _, err := loadTaskForUser(ctx, taskID, userID); err == nil && len(current.Checklist) >= models.MaxChecklistItems {
+            c.JSON(400, gin.H{"error": fmt.Sprintf("A checklist can have at most %d items", models.MaxChecklistItems)})
+            return
+        }
+        respondWithTaskUpdateError(c, staleTaskError(ctx, task, userID))
+        return
+    }
+
+    recordChecklistActivity(ctx, task.ID, userID, "checklist", nil, item.Text)
+    respondWithUpdatedTask(c, taskID, userID, "Checklist item added")
+}
+
+// UpdateChecklistItem changes the text of a checklist item
+func UpdateChecklistItem(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    itemID, _ := primitive.ObjectIDFromHex(c.Param("itemId"))
+    userID := currentUserID(c)
+
+    var input struct {
+        Text string `json:"text"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+    text, errs := validateChecklistText(input.Text)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, version, ok := loadTaskForChecklistEdit(c, ctx, taskID, userID)
+    if !ok {
+        return
+    }
+    item := findChecklistItem(task, itemID)
+    if item == nil {
+        c.JSON(404, gin.H{"error": "Checklist item not found"})
+        return
+    }
+
+    filter := checklistFilter(task, userID, role, version)
+    filter["checklist.id"] = itemID
+    matched, err := updateTask(filter, bson.M{"$set": bson.M{
+        "checklist.$.text": text,
+        "updated_at":       time.Now(),
+    }})
+    if err != nil {
+        respondWithError(c, 500, "Failed to update checklist item", err)
+        return
+    }
+    if matched == 0 {
+        respondWithChecklistMiss(c, ctx, task, userID, version)
+        return
+    }
+
+    recordChecklistActivity(ctx, task.ID, userID, "checklist."+itemID.Hex()+".text", item.Text, text)
+    respondWithUpdatedTask(c, taskID, userID, "Checklist item updated")
+}
+
+// ToggleChecklistItem checks or unchecks a checklist item. Anyone who can
+// see the task may do it. The body's done sets the state outright, which
+// is safe when several people check the same item; without it the item is
+// flipped, and a flip that races with another fails with 409.
+func ToggleChecklistItem(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    itemID, _ := primitive.ObjectIDFromHex(c.Param("itemId"))
+    userID := currentUserID(c)
+
+    var input struct {
+        Done *bool `json:"done"`
+    }
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&input); err != nil {
+            respondWithError(c, 400, "Invalid request payload", err)
+            return
+        }
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+    item := findChecklistItem(task, itemID)
+    if item == nil {
+        c.JSON(404, gin.H{"error": "Checklist item not found"})
+        return
+    }
+
+    done := !item.Done
+    if input.Done != nil {
+        done = *input.Done
+        if done == item.Done {
+            respondWithUpdatedTask(c, taskID, userID, "Checklist item unchanged")
+            return
+        }
+    }
+
+    update := bson.M{"$set": bson.M{"checklist.$.done": done, "updated_at": time.Now()}}
+    if done {
+        update["$set"].(bson.M)["checklist.$.done_by"] = userID
+        update["$set"].(bson.M)["checklist.$.done_at"] = time.Now()
+    } else {
+        update["$unset"] = bson.M{"checklist.$.done_by": "", "checklist.$.done_at": ""}
+    }
+
+    // Only the write that actually changes the item's state matches
+    filter := checklistFilter(task, userID, role, version)
+    filter["checklist"] = bson.M{"$elemMatch": bson.M{"id": itemID, "done": !done}}
+    matched, err := updateTask(filter, update)
+    if err != nil {
+        respondWithError(c, 500, "Failed to update checklist item", err)
+        return
+    }
+    if matched == 0 {
+        if version != nil {
+            respondWithTaskUpdateError(c, staleTaskError(ctx, task, userID))
+            return
+        }
+        current, _, err := loadTaskForUser(ctx, taskID, userID)
+        if err != nil {
+            respondWithTaskLookupError(c, err)
+            return
+        }
+        currentItem := findChecklistItem(current, itemID)
+        if currentItem == nil {
+            c.JSON(404, gin.H{"error": "Checklist item not found"})
+            return
+        }
+        if input.Done != nil && currentItem.Done == done {
+            // Someone else got there first; the result is what was asked for
+            respondWithUpdatedTask(c, taskID, userID, "Checklist item unchanged")
+            return
+        }
+        c.JSON(409, gin.H{"error": "Checklist item was changed by someone else", "item": currentItem})
+        return
+    }
+
+    recordChecklistActivity(ctx, task.ID, userID, "checklist."+itemID.Hex()+".done", !done, done)
+    respondWithUpdatedTask(c, taskID, userID, "Checklist item updated")
+}
+
+// ReorderChecklist puts the checklist in the order of item_ids, which must
+// list every item once. The write only applies to the version of the task
+// the order was computed from.
+func ReorderChecklist(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        ItemIDs []string `json:"item_ids"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, version, ok := loadTaskForChecklistEdit(c, ctx, taskID, userID)
+    if !ok {
+        return
+    }
+
+    reordered, err := reorderChecklistItems(task.Checklist, input.ItemIDs)
+    if err != nil {
+        respondWithValidationErrors(c, models.ValidationErrors{{Field: "item_ids", Message: err.Error()}})
+        return
+    }
+
+    filter := checklistFilter(task, userID, role, version)
+    filter["version"] = task.Version
+    matched, err := updateTask(filter, bson.M{"$set": bson.M{
+        "checklist":  reordered,
+        "updated_at": time.Now(),
+    }})
+    if err != nil {
+        respondWithError(c, 500, "Failed to reorder checklist", err)
+        return
+    }
+    if matched == 0 {
+        respondWithTaskUpdateError(c, staleTaskError(ctx, task, userID))
+        return
+    }
+
+    recordChecklistActivity(ctx, task.ID, userID, "checklist", checklistTexts(task.Checklist), checklistTexts(reordered))
+    respondWithUpdatedTask(c, taskID, userID, "Checklist reordered")
+}
+
+// DeleteChecklistItem removes an item from a task's checklist
+func DeleteChecklistItem(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    itemID, _ := primitive.ObjectIDFromHex(c.Param("itemId"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, version, ok := loadTaskForChecklistEdit(c, ctx, taskID, userID)
+    if !ok {
+        return
+    }
+    item := findChecklistItem(task, itemID)
+    if item == nil {
+        c.JSON(404, gin.H{"error": "Checklist item not found"})
+        return
+    }
+
+    filter := checklistFilter(task, userID, role, version)
+    filter["checklist.id"] = itemID
+    matched, err := updateTask(filter, bson.M{
+        "$pull": bson.M{"checklist": bson.M{"id": itemID}},
+        "$set":  bson.M{"updated_at": time.Now()},
+    })
+    if err != nil {
+        respondWithError(c, 500, "Failed to delete checklist item", err)
+        return
+    }
+    if matched == 0 {
+        respondWithChecklistMiss(c, ctx, task, userID, version)
+        return
+    }
+
+    recordChecklistActivity(ctx, task.ID, userID, "checklist", item.Text, nil)
+    respondWithUpdatedTask(c, taskID, userID, "Checklist item deleted")
+}
+
+// ------------------ Helper Functions ------------------
+
+// Load a task whose checklist the user wants to change. Only roles that
+// may edit the checklist field can add, edit, reorder or delete items.
+// version is the one If-Match expects, if any. ok is false when a response
+// has already been sent.
+func loadTaskForChecklistEdit(c *gin.Context, ctx context.Context, taskID, userID primitive.ObjectID) (*models.Task, services.TaskRole, *int64, bool) {
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return nil, role, nil, false
+    }
+    if !role.CanUpdateField("checklist") {
+        c.JSON(403, gin.H{"error": "Only the task creator can edit the checklist"})
+        return nil, role, nil, false
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return nil, role, nil, false
+    }
+    return task, role, version, true
+}
+
+// checklistFilter matches the task while the user keeps their role and it
+// stays out of the trash, and at version when one is given
+func checklistFilter(task *models.Task, userID primitive.ObjectID, role services.TaskRole, version *int64) bson.M {
+    filter := taskAccessFilter(task.ID, userID, role)
+    filter["deleted_at"] = nil
+    if version != nil {
+        filter["version"] = *version
+    }
+    return filter
+}
+
+// Respond to a write to a checklist item that matched nothing. The item was
+// on the task as loaded, so with a version to match the task has changed.
+func respondWithChecklistMiss(c *gin.Context, ctx context.Context, task *models.Task, userID primitive.ObjectID, version *int64) {
+    if version != nil {
+        respondWithTaskUpdateError(c, staleTaskError(ctx, task, userID))
+        return
+    }
+    c.JSON(404, gin.H{"error": "Checklist item not found"})
+}
+
+func validateChecklistText(text string) (string, models.ValidationErrors) {
+    text = strings.TrimSpace(text)
+    if text == "" {
+        return "", models.ValidationErrors{{Field: "text", Message: "cannot be empty"}}
+    }
+    if utf8.RuneCountInString(text) > models.ChecklistItemMaxLength {
+        return "", models.ValidationErrors{{
+            Field:   "text",
+            Message: fmt.Sprintf("must be at most %d characters", models.ChecklistItemMaxLength),
+        }}
+    }
+    return text, nil
+}
+
+func findChecklistItem(task *models.Task, itemID primitive.ObjectID) *models.ChecklistItem {
+    for i := range task.Checklist {
+        if task.Checklist[i].ID == itemID {
+            return &task.Checklist[i]
+        }
+    }
+    return nil
+}
+
+// reorderChecklistItems returns the items in the order of ids, which must
+// name each item exactly once
+func reorderChecklistItems(items []models.ChecklistItem, ids []string) ([]models.ChecklistItem, error) {
+    if len(ids) != len(items) {
+        return nil, fmt.Errorf("must list all %d checklist items", len(items))
+    }
+    byID := make(map[primitive.ObjectID]models.ChecklistItem, len(items))
+    for _, item := range items {
+        byID[item.ID] = item
+    }
+
+    reordered := make([]models.ChecklistItem, 0, len(items))
+    for _, raw := range ids {
+        id, err := primitive.ObjectIDFromHex(raw)
+        if err != nil {
+            return nil, fmt.Errorf("%q is not a valid item ID", raw)
+        }
+        item, ok := byID[id]
+        if !ok {
+            return nil, fmt.Errorf("%q is not a checklist item or is listed twice", raw)
+        }
+        delete(byID, id)
+        reordered = append(reordered, item)
+    }
+    return reordered, nil
+}
+
+func checklistTexts(items []models.ChecklistItem) []string {
+    texts := make([]string, len(items))
+    for i, item := range items {
+        texts[i] = item.Text
+    }
+    return texts
+}
+
+// Record a checklist change in the task's history
+func recordChecklistActivity(ctx context.Context, taskID, userID primitive.ObjectID, field string, from, to interface{}) {
+    entry := newActivity(taskID, userID, models.ActivityChecklistUpdated)
+    entry.Changes = []models.FieldChange{{Field: field, From: from, To: to}}
+    recordActivity(ctx, entry)
+}
@@ -0,0 +1,421 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "regexp"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+const commentCollection = "comments"
+
+// Websocket event types sent to the people following a task
+const (
+    eventCommentCreated   = "comment_created"
+    eventCommentUpdated   = "comment_updated"
+    eventCommentDeleted   = "comment_deleted"
+    eventCommentMentioned = "comment_mentioned"
+)
+
+var errCommentNotFound = errors.New("comment not found")
+
+// CreateComment adds a comment to a task, or a reply when parent_id names a
+// top-level comment on the same task
+func CreateComment(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        Body     string  `json:"body"`
+        ParentID *string `json:"parent_id"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    body, errs := validateCommentBody(input.Body)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, _, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    comment := models.Comment{
+        ID:        primitive.NewObjectID(),
+        TaskID:    task.ID,
+        AuthorID:  userID,
+        Body:      body,
+        CreatedAt: time.Now(),
+    }
+
+    if input.ParentID != nil {
+        parentID, err := primitive.ObjectIDFromHex(*input.ParentID)
+        if err != nil {
+            respondWithValidationErrors(c, models.ValidationErrors{{Field: "parent_id", Message: "must be a valid ID"}})
+            return
+        }
+        parent, err := loadComment(ctx, task.ID, parentID)
+        if errors.Is(err, errCommentNotFound) {
+            respondWithValidationErrors(c, models.ValidationErrors{{Field: "parent_id", Message: "parent comment not found"}})
+            return
+        }
+        if err != nil {
+            respondWithError(c, 500, "Failed to fetch comment", err)
+            return
+        }
+        if parent.ParentID != nil {
+            respondWithValidationErrors(c, models.ValidationErrors{{Field: "parent_id", Message: "replies cannot be replied to"}})
+            return
+        }
+        comment.ParentID = &parent.ID
+    }
+
+    mentioned, err := resolveMentions(ctx, services.ParseMentions(body))
+    if err != nil {
+        respondWithError(c, 500, "Failed to resolve mentions", err)
+        return
+    }
+    comment.Mentions = mentioned
+
+    if _, err := database.GetCollection(commentCollection).InsertOne(ctx, comment); err != nil {
+        respondWithError(c, 500, "Failed to create comment", err)
+        return
+    }
+
+    recordCommentActivity(ctx, task.ID, models.ActivityCommentAdded, comment.ID, userID)
+    notifyCommentEvent(task, eventCommentCreated, comment, userID)
+    notifyMentions(task, comment, nil, userID)
+    c.JSON(201, gin.H{"message": "Comment created successfully", "comment": comment})
+}
+
+// GetComments lists the comments on a task as threads, oldest first
+func GetComments(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, _, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    cursor, err := database.GetCollection(commentCollection).Find(ctx,
+        bson.M{"task_id": task.ID},
+        options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch comments", err)
+        return
+    }
+
+    var comments []models.Comment
+    if err := cursor.All(ctx, &comments); err != nil {
+        respondWithError(c, 500, "Failed to fetch comments", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"task_id": task.ID, "comments": buildCommentThreads(comments)})
+}
+
+// UpdateComment replaces the body of a comment. Only its author may edit it;
+// the previous body is kept in the comment's history.
+func UpdateComment(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    commentID, _ := primitive.ObjectIDFromHex(c.Param("commentId"))
+    userID := currentUserID(c)
+
+    var input struct {
+        Body string `json:"body"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    body, errs := validateCommentBody(input.Body)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, _, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    comment, err := loadComment(ctx, task.ID, commentID)
+    if err != nil {
+        respondWithCommentLookupError(c, err)
+        return
+    }
+    if comment.AuthorID != userID {
+        c.JSON(403, gin.H{"error": "Only the author can edit this comment"})
+        return
+    }
+    if comment.Body == body {
+        c.JSON(200, gin.H{"message": "Comment updated successfully", "comment": comment})
+        return
+    }
+
+    mentioned, err := resolveMentions(ctx, services.ParseMentions(body))
+    if err != nil {
+        respondWithError(c, 500, "Failed to resolve mentions", err)
+        return
+    }
+
+    now := time.Now()
+    set := bson.M{"body": body, "edited_at": now, "mentions": mentioned}
+    if len(mentioned) == 0 {
+        set["mentions"] = []primitive.ObjectID{}
+    }
+
+    // Matching on the old body makes a concurrent edit fail instead of
+    // dropping a revision from the history
+    var updated models.Comment
+    err = database.GetCollection(commentCollection).FindOneAndUpdate(ctx,
+        bson.M{"_id": comment.ID, "author_id": userID, "body": comment.Body},
+        bson.M{
+            "$set":  set,
+            "$push": bson.M{"history": models.CommentRevision{Body: comment.Body, EditedAt: now}},
+        },
+        options.FindOneAndUpdate().SetReturnDocument(options.After),
+    ).Decode(&updated)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        c.JSON(409, gin.H{"error": "Comment was changed by another request, reload and try again"})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to update comment", err)
+        return
+    }
+
+    recordCommentActivity(ctx, task.ID, models.ActivityCommentEdited, updated.ID, userID)
+    notifyCommentEvent(task, eventCommentUpdated, updated, userID)
+    notifyMentions(task, updated, comment.Mentions, userID)
+    c.JSON(200, gin.H{"message": "Comment updated successfully", "comment": updated})
+}
+
+// DeleteComment removes a comment and its replies. The author and the task
+// creator may delete it.
+func DeleteComment(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    commentID, _ := primitive.ObjectIDFromHex(c.Param("commentId"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    comment, err := loadComment(ctx, task.ID, commentID)
+    if err != nil {
+        respondWithCommentLookupError(c, err)
+        return
+    }
+    if comment.AuthorID != userID && role != services.TaskRoleCreator {
+        c.JSON(403, gin.H{"error": "Only the author or the task creator can delete this comment"})
+        return
+    }
+
+    result, err := database.GetCollection(commentCollection).DeleteMany(ctx, bson.M{
+        "task_id": task.ID,
+        "$or":     []bson.M{{"_id": comment.ID}, {"parent_id": comment.ID}},
+    })
+    if err != nil {
+        respondWithError(c, 500, "Failed to delete comment", err)
+        return
+    }
+
+    recordCommentActivity(ctx, task.ID, models.ActivityCommentDeleted, comment.ID, userID)
+    notifyCommentEvent(task, eventCommentDeleted, gin.H{"id": comment.ID, "task_id": task.ID}, userID)
+    c.JSON(200, gin.H{"message": "Comment deleted successfully", "deleted": result.DeletedCount})
+}
+
+// ------------------ Helper Functions ------------------
+
+// Trim a comment body and check its length
+func validateCommentBody(body string) (string, models.ValidationErrors) {
+    body = strings.TrimSpace(body)
+    if body == "" {
+        return "", models.ValidationErrors{{Field: "body", Message: "is required"}}
+    }
+    if len([]rune(body)) > models.CommentMaxLength {
+        return "", models.ValidationErrors{{
+            Field:   "body",
+            Message: fmt.Sprintf("must be at most %d characters", models.CommentMaxLength),
+        }}
+    }
+    return body, nil
+}
+
+// Fetch a comment that belongs to the given task
+func loadComment(ctx context.Context, taskID, commentID primitive.ObjectID) (*models.Comment, error) {
+    var comment models.Comment
+    err := database.GetCollection(commentCollection).
+        FindOne(ctx, bson.M{"_id": commentID, "task_id": taskID}).
+        Decode(&comment)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, errCommentNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &comment, nil
+}
+
+func respondWithCommentLookupError(c *gin.Context, err error) {
+    if errors.Is(err, errCommentNotFound) {
+        c.JSON(404, gin.H{"error": "Comment not found"})
+        return
+    }
+    respondWithError(c, 500, "Failed to fetch comment", err)
+}
+
+// Look up the users behind @mentions. Emails must match exactly; a handle
+// matches the part of an email before the @ and is only resolved when it
+// points at a single user. Unknown mentions are ignored.
+func resolveMentions(ctx context.Context, mentions services.Mentions) ([]primitive.ObjectID, error) {
+    if mentions.Empty() {
+        return nil, nil
+    }
+
+    var or []bson.M
+    for _, email := range mentions.Emails {
+        or = append(or, bson.M{"email": bson.M{"$regex": "^" + regexp.QuoteMeta(email) + "$", "$options": "i"}})
+    }
+    for _, handle := range mentions.Handles {
+        or = append(or, bson.M{"email": bson.M{"$regex": "^" + regexp.QuoteMeta(handle) + "@", "$options": "i"}})
+    }
+
+    cursor, err := database.GetCollection(userCollection).Find(ctx,
+        bson.M{"$or": or},
+        options.Find().SetProjection(bson.M{"_id": 1, "email": 1}),
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    var users []models.User
+    if err := cursor.All(ctx, &users); err != nil {
+        return nil, err
+    }
+
+    byHandle := make(map[string][]primitive.ObjectID)
+    var resolved []primitive.ObjectID
+    for _, user := range users {
+        email := strings.ToLower(user.Email)
+        for _, wanted := range mentions.Emails {
+            if email == wanted && !containsObjectID(resolved, user.ID) {
+                resolved = append(resolved, user.ID)
+            }
+        }
+        if at := strings.Index(email, "@"); at > 0 {
+            byHandle[email[:at]] = append(byHandle[email[:at]], user.ID)
+        }
+    }
+    for _, handle := range mentions.Handles {
+        if ids := byHandle[handle]; len(ids) == 1 && !containsObjectID(resolved, ids[0]) {
+            resolved = append(resolved, ids[0])
+        }
+    }
+    return resolved, nil
+}
+
+// Group comments into threads; comments must be sorted oldest first
+func buildCommentThreads(comments []models.Comment) []models.CommentThread {
+    threads := []models.CommentThread{}
+    index := make(map[primitive.ObjectID]int)
+
+    for _, comment := range comments {
+        if comment.ParentID == nil {
+            index[comment.ID] = len(threads)
+            threads = append(threads, models.CommentThread{Comment: comment, Replies: []models.Comment{}})
+        }
+    }
+    for _, comment := range comments {
+        if comment.ParentID == nil {
+            continue
+        }
+        if i, ok := index[*comment.ParentID]; ok {
+            threads[i].Replies = append(threads[i].Replies, comment)
+        }
+    }
+    return threads
+}
+
+// taskAudience lists everyone who can see a task
+func taskAudience(task *models.Task) []primitive.ObjectID {
+    return mergeAssignees([]primitive.ObjectID{task.CreatedBy}, task.AssignedTo)
+}
+
+// Send a comment event to everyone who can see the task except the actor
+func notifyCommentEvent(task *models.Task, event string, data interface{}, by primitive.ObjectID) {
+    notifyTaskAudience(task, event, data, by)
+}
+
+// Tell newly mentioned users about a comment. Mentions of people who can't
+// see the task are kept on the comment but not notified.
+func notifyMentions(task *models.Task, comment models.Comment, previous []primitive.ObjectID, by primitive.ObjectID) {
+    var recipients []string
+    for _, id := range comment.Mentions {
+        if id == by || containsObjectID(previous, id) {
+            continue
+        }
+        if services.RoleForTask(*task, id).CanView() {
+            recipients = append(recipients, id.Hex())
+        }
+    }
+
+    services.SendToUsers(recipients, eventCommentMentioned, gin.H{
+        "task_id":    task.ID.Hex(),
+        "title":      task.Title,
+        "comment_id": comment.ID.Hex(),
+        "author_id":  by.Hex(),
+    })
+}
+
+// Record a comment event in the task's history
+func recordCommentActivity(ctx context.Context, taskID primitive.ObjectID, activityType string, commentID, by primitive.ObjectID) {
+    entry := newActivity(taskID, by, activityType)
+    entry.CommentID = &commentID
+    recordActivity(ctx, entry)
+}
+
+// Remove every comment on the given tasks
+func deleteTaskComments(ctx context.Context, taskIDs []primitive.ObjectID) error {
+    _, err := database.GetCollection(commentCollection).DeleteMany(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}})
+    return err
+}
+
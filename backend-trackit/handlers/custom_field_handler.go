@@ -0,0 +1,357 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/config"
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+const customFieldCollection = "custom_fields"
+
+// errCustomFieldNotFound is returned when a custom field does not exist
+var errCustomFieldNotFound = errors.New("custom field not found")
+
+// GetCustomFields lists the custom field definitions, ordered by key
+func GetCustomFields(c *gin.Context) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    fields, err := loadCustomFieldList(ctx)
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch custom fields", err)
+        return
+    }
+    c.JSON(200, gin.H{"custom_fields": fields})
+}
+
+// CreateCustomField defines a new custom field. The key and type are fixed
+// once the field exists.
+func CreateCustomField(c *gin.Context) {
+    var input struct {
+        Key     string   `json:"key"`
+        Name    string   `json:"name"`
+        Type    string   `json:"type"`
+        Options []string `json:"options"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    now := time.Now()
+    field := models.CustomField{
+        ID:        primitive.NewObjectID(),
+        Key:       input.Key,
+        Name:      strings.TrimSpace(input.Name),
+        Type:      input.Type,
+        Options:   input.Options,
+        CreatedBy: currentUserID(c),
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    if errs := field.Validate(); errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    collection := database.GetCollection(customFieldCollection)
+    count, err := collection.CountDocuments(ctx, bson.M{})
+    if err != nil {
+        respondWithError(c, 500, "Failed to create custom field", err)
+        return
+    }
+    if count >= models.MaxCustomFields {
+        c.JSON(400, gin.H{"error": fmt.Sprintf("At most %d custom fields can be defined", models.MaxCustomFields)})
+        return
+    }
+
+    if _, err := collection.InsertOne(ctx, field); err != nil {
+        if mongo.IsDuplicateKeyError(err) {
+            c.JSON(409, gin.H{"error": fmt.Sprintf("A custom field with key %q already exists", field.Key)})
+            return
+        }
+        respondWithError(c, 500, "Failed to create custom field", err)
+        return
+    }
+
+    c.JSON(201, gin.H{"message": "Custom field created successfully", "custom_field": field})
+}
+
+// UpdateCustomField renames a custom field or changes its options. Options
+// that tasks still use cannot be removed. Only the field's creator or an
+// admin can change it.
+func UpdateCustomField(c *gin.Context) {
+    fieldID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    var input struct {
+        Key     *string   `json:"key"`
+        Name    *string   `json:"name"`
+        Type    *string   `json:"type"`
+        Options *[]string `json:"options"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    field, err := loadCustomField(ctx, fieldID)
+    if err != nil {
+        respondWithCustomFieldLookupError(c, err)
+        return
+    }
+    if !canEditCustomField(c, field) {
+        c.JSON(403, gin.H{"error": "Only the field's creator or an admin can change it"})
+        return
+    }
+
+    var errs models.ValidationErrors
+    if input.Key != nil && *input.Key != field.Key {
+        errs = append(errs, models.FieldError{Field: "key", Message: "cannot be changed"})
+    }
+    if input.Type != nil && *input.Type != field.Type {
+        errs = append(errs, models.FieldError{Field: "type", Message: "cannot be changed"})
+    }
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    updated := *field
+    if input.Name != nil {
+        updated.Name = strings.TrimSpace(*input.Name)
+    }
+    if input.Options != nil {
+        updated.Options = *input.Options
+    }
+    if errs := updated.Validate(); errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    if removed := removedOptions(field.Options, updated.Options); len(removed) > 0 {
+        inUse, err := database.GetCollection(taskCollection).CountDocuments(ctx, bson.M{
+            "custom_fields." + field.Key: bson.M{"$in": removed},
+        })
+        if err != nil {
+            respondWithError(c, 500, "Failed to update custom field", err)
+            return
+        }
+        if inUse > 0 {
+            c.JSON(409, gin.H{"error": "Options still in use by tasks cannot be removed", "options": removed, "tasks": inUse})
+            return
+        }
+    }
+
+    updated.UpdatedAt = time.Now()
+    if _, err := database.GetCollection(customFieldCollection).UpdateOne(ctx,
+        bson.M{"_id": field.ID},
+        bson.M{"$set": bson.M{"name": updated.Name, "options": updated.Options, "updated_at": updated.UpdatedAt}},
+    ); err != nil {
+        respondWithError(c, 500, "Failed to update custom field", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"message": "Custom field updated successfully", "custom_field": updated})
+}
+
+// DeleteCustomField removes a custom field definition along with its value
+// on every task. Only the field's creator or an admin can delete it.
+func DeleteCustomField(c *gin.Context) {
+    fieldID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    field, err := loadCustomField(ctx, fieldID)
+    if err != nil {
+        respondWithCustomFieldLookupError(c, err)
+        return
+    }
+    if !canEditCustomField(c, field) {
+        c.JSON(403, gin.H{"error": "Only the field's creator or an admin can change it"})
+        return
+    }
+
+    // Remove the definition first, so no new values are written while the
+    // old ones are being cleared
+    if _, err := database.GetCollection(customFieldCollection).DeleteOne(ctx, bson.M{"_id": field.ID}); err != nil {
+        respondWithError(c, 500, "Failed to delete custom field", err)
+        return
+    }
+
+    path := "custom_fields." + field.Key
+    update := bson.M{"$unset": bson.M{path: ""}}
+    bumpVersion(update)
+    result, err := database.GetCollection(taskCollection).UpdateMany(ctx, bson.M{path: bson.M{"$exists": true}}, update)
+    if err != nil {
+        respondWithError(c, 500, "Failed to clear custom field values", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"message": "Custom field deleted successfully", "tasks_updated": result.ModifiedCount})
+}
+
+// ------------------ Helper Functions ------------------
+
+// Load every custom field definition, ordered by key
+func loadCustomFieldList(ctx context.Context) ([]models.CustomField, error) {
+    cursor, err := database.GetCollection(customFieldCollection).Find(ctx, bson.M{},
+        options.Find().SetSort(bson.D{{Key: "key", Value: 1}}),
+    )
+    if err != nil {
+        return nil, err
+    }
+    fields := []models.CustomField{}
+    if err := cursor.All(ctx, &fields); err != nil {
+        return nil, err
+    }
+    return fields, nil
+}
+
+// Load every custom field definition, keyed by field key
+func loadCustomFields(ctx context.Context) (map[string]models.CustomField, error) {
+    list, err := loadCustomFieldList(ctx)
+    if err != nil {
+        return nil, err
+    }
+    fields := make(map[string]models.CustomField, len(list))
+    for _, field := range list {
+        fields[field.Key] = field
+    }
+    return fields, nil
+}
+
+func loadCustomField(ctx context.Context, fieldID primitive.ObjectID) (*models.CustomField, error) {
+    var field models.CustomField
+    err := database.GetCollection(customFieldCollection).FindOne(ctx, bson.M{"_id": fieldID}).Decode(&field)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, errCustomFieldNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &field, nil
+}
+
+// Custom fields are shared by everyone, so only the user who defined a field
+// or an admin may change or remove it
+func canEditCustomField(c *gin.Context, field *models.CustomField) bool {
+    userID := currentUserID(c)
+    return field.CreatedBy == userID || config.IsAdmin(userID.Hex())
+}
+
+// Respond to a failed loadCustomField
+func respondWithCustomFieldLookupError(c *gin.Context, err error) {
+    if errors.Is(err, errCustomFieldNotFound) {
+        c.JSON(404, gin.H{"error": "Custom field not found"})
+        return
+    }
+    respondWithError(c, 500, "Failed to fetch custom field", err)
+}
+
+// resolveCustomFieldValues checks a custom_fields payload against the field
+// definitions. It returns the values to store, converted, and the keys sent
+// as null, which are to be removed.
+func resolveCustomFieldValues(ctx context.Context, raw map[string]interface{}) (map[string]interface{}, []string, error) {
+    fields, err := loadCustomFields(ctx)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    values := make(map[string]interface{})
+    var unset []string
+    var users []primitive.ObjectID
+    var errs models.ValidationErrors
+    for key, value := range raw {
+        field, ok := fields[key]
+        if !ok {
+            errs = append(errs, models.FieldError{Field: "custom_fields." + key, Message: "unknown custom field"})
+            continue
+        }
+        if value == nil {
+            unset = append(unset, key)
+            continue
+        }
+        converted, err := field.Convert(value)
+        if err != nil {
+            errs = append(errs, models.FieldError{Field: "custom_fields." + key, Message: err.Error()})
+            continue
+        }
+        if id, ok := converted.(primitive.ObjectID); ok {
+            users = append(users, id)
+        }
+        values[key] = converted
+    }
+
+    if len(errs) == 0 && len(users) > 0 {
+        userErrs, err := validateAssigneesExist(ctx, users)
+        if err != nil {
+            return nil, nil, err
+        }
+        for _, e := range userErrs {
+            errs = append(errs, models.FieldError{Field: "custom_fields", Message: e.Message})
+        }
+    }
+    if len(errs) > 0 {
+        sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+        return nil, nil, validationFailed(errs)
+    }
+    sort.Strings(unset)
+    return values, unset, nil
+}
+
+// expandCustomFieldChange turns the custom_fields map of a change into a
+// write per key, so values the change doesn't mention are kept
+func expandCustomFieldChange(ctx context.Context, ch *taskChange) error {
+    raw, ok := ch.Set["custom_fields"].(map[string]interface{})
+    if !ok {
+        return nil
+    }
+    values, unset, err := resolveCustomFieldValues(ctx, raw)
+    if err != nil {
+        return err
+    }
+
+    delete(ch.Set, "custom_fields")
+    for key, value := range values {
+        ch.Set["custom_fields."+key] = value
+    }
+    for _, key := range unset {
+        ch.Unset["custom_fields."+key] = ""
+    }
+    return nil
+}
+
+// removedOptions lists the options in before that are missing from after
+func removedOptions(before, after []string) []string {
+    kept := make(map[string]bool, len(after))
+    for _, option := range after {
+        kept[option] = true
+    }
+    var removed []string
+    for _, option := range before {
+        if !kept[option] {
+            removed = append(removed, option)
+        }
+    }
+    return removed
+}
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+    "context"
+    "sort"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+// maxDependencyDepth bounds how far dependency graphs are followed
+const maxDependencyDepth = 50
+
+// DependencyNode is a task in a dependency graph
+type DependencyNode struct {
+    ID      primitive.ObjectID `json:"id"`
+    Title   string             `json:"title"`
+    Status  string             `json:"status"`
+    DueDate *time.Time         `json:"due_date,omitempty"`
+    Open    bool               `json:"open"`
+}
+
+// DependencyEdge says that From must be finished before To
+type DependencyEdge struct {
+    From primitive.ObjectID `json:"from"`
+    To   primitive.ObjectID `json:"to"`
+}
+
+// AddDependency records that a task is blocked by another task. The body
+// names either the blocker ({"blocked_by": id}) or the task this one
+// blocks ({"blocks": id}); the caller must own the task that gets blocked.
+func AddDependency(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        BlockedBy string `json:"blocked_by"`
+        Blocks    string `json:"blocks"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+    if (input.BlockedBy == "") == (input.Blocks == "") {
+        c.JSON(400, gin.H{"error": "Provide exactly one of blocked_by or blocks"})
+        return
+    }
+
+    otherHex := input.BlockedBy
+    if input.Blocks != "" {
+        otherHex = input.Blocks
+    }
+    otherID, err := primitive.ObjectIDFromHex(otherHex)
+    if err != nil {
+        c.JSON(400, gin.H{"error": "Invalid task ID"})
+        return
+    }
+
+    blockedID, blockerID := taskID, otherID
+    if input.Blocks != "" {
+        blockedID, blockerID = otherID, taskID
+    }
+    if blockedID == blockerID {
+        c.JSON(400, gin.H{"error": "A task cannot block itself"})
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    blocked, role, err := loadTaskForUser(ctx, blockedID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    if !role.CanUpdateField("blocked_by") {
+        c.JSON(403, gin.H{"error": "Only the task creator can change its dependencies"})
+        return
+    }
+    blocker, _, err := loadTaskForUser(ctx, blockerID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    // If-Match refers to the task in the path, which is only written to
+    // when it is the one being blocked
+    target := blocked
+    if input.Blocks != "" {
+        target = blocker
+    }
+    version, ok := checkIfMatch(c, target)
+    if !ok {
+        return
+    }
+
+    // The new edge closes a cycle if the blocker already waits on this task
+    upstream, err := findUpstreamIDs(ctx, blockerID)
+    if err != nil {
+        respondWithError(c, 500, "Failed to check dependencies", err)
+        return
+    }
+    if upstream[blockedID] {
+        c.JSON(409, gin.H{"error": "Adding this dependency would create a cycle"})
+        return
+    }
+
+    filter := taskAccessFilter(blocked.ID, userID, role)
+    if version != nil && target == blocked {
+        filter["version"] = *version
+    }
+    matchedCount, err := updateTask(filter, bson.M{
+        "$addToSet": bson.M{"blocked_by": blockerID},
+        "$set":      bson.M{"updated_at": time.Now()},
+    })
+    if err != nil {
+        respondWithError(c, 500, "Failed to add dependency", err)
+        return
+    }
+    if matchedCount == 0 {
+        respondWithTaskUpdateError(c, staleTaskError(ctx, blocked, userID))
+        return
+    }
+
+    if !containsObjectID(blocked.BlockedBy, blockerID) {
+        after := append(append([]primitive.ObjectID(nil), blocked.BlockedBy...), blockerID)
+        recordDependencyActivity(ctx, blocked, after, userID)
+    }
+
+    c.JSON(200, gin.H{
+        "message":    "Dependency added successfully",
+        "dependency": DependencyEdge{From: blockerID, To: blockedID},
+    })
+}
+
+// RemoveDependency removes a blocker from a task
+func RemoveDependency(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    blockerID, _ := primitive.ObjectIDFromHex(c.Param("blockerId"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    if !role.CanUpdateField("blocked_by") {
+        c.JSON(403, gin.H{"error": "Only the task creator can change its dependencies"})
+        return
+    }
+    if !containsObjectID(task.BlockedBy, blockerID) {
+        c.JSON(404, gin.H{"error": "Dependency not found"})
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+
+    filter := taskAccessFilter(task.ID, userID, role)
+    if version != nil {
+        filter["version"] = *version
+    }
+    matchedCount, err := updateTask(filter, bson.M{
+        "$pull": bson.M{"blocked_by": blockerID},
+        "$set":  bson.M{"updated_at": time.Now()},
+    })
+    if err != nil {
+        respondWithError(c, 500, "Failed to remove dependency", err)
+        return
+    }
+    if matchedCount == 0 {
+        respondWithTaskUpdateError(c, staleTaskError(ctx, task, userID))
+        return
+    }
+
+    var after []primitive.ObjectID
+    for _, id := range task.BlockedBy {
+        if id != blockerID {
+            after = append(after, id)
+        }
+    }
+    recordDependencyActivity(ctx, task, after, userID)
+
+    c.JSON(200, gin.H{"message": "Dependency removed successfully"})
+}
+
+// GetDependencies returns the transitive dependency graph around a task:
+// everything it waits on and everything waiting on it, limited to tasks the
+// user can see. critical_path is the longest chain of open blockers that
+// ends at the task, in the order they have to be finished.
+func GetDependencies(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, _, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    visible := visibleTasksFilter(userID)
+    cursor, err := database.GetCollection(taskCollection).Aggregate(ctx, mongo.Pipeline{
+        {{Key: "$match", Value: bson.M{"_id": task.ID}}},
+        {{Key: "$graphLookup", Value: bson.M{
+            "from":                    taskCollection,
+            "startWith":               "$blocked_by",
+            "connectFromField":        "blocked_by",
+            "connectToField":          "_id",
+            "as":                      "upstream",
+            "maxDepth":                maxDependencyDepth,
+            "restrictSearchWithMatch": visible,
+        }}},
+        {{Key: "$graphLookup", Value: bson.M{
+            "from":                    taskCollection,
+            "startWith":               "$_id",
+            "connectFromField":        "_id",
+            "connectToField":          "blocked_by",
+            "as":                      "downstream",
+            "maxDepth":                maxDependencyDepth,
+            "restrictSearchWithMatch": visible,
+        }}},
+    })
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch dependencies", err)
+        return
+    }
+
+    var result []struct {
+        Upstream   []models.Task `bson:"upstream"`
+        Downstream []models.Task `bson:"downstream"`
+    }
+    if err := cursor.All(ctx, &result); err != nil {
+        respondWithError(c, 500, "Failed to fetch dependencies", err)
+        return
+    }
+    if len(result) == 0 {
+        c.JSON(404, gin.H{"error": "Task not found"})
+        return
+    }
+
+    tasks := map[primitive.ObjectID]models.Task{task.ID: *task}
+    for _, t := range append(result[0].Upstream, result[0].Downstream...) {
+        tasks[t.ID] = t
+    }
+
+    nodes := make([]DependencyNode, 0, len(tasks))
+    edges := []DependencyEdge{}
+    for _, t := range tasks {
+        nodes = append(nodes, DependencyNode{
+            ID:      t.ID,
+            Title:   t.Title,
+            Status:  t.Status,
+            DueDate: t.DueDate,
+            Open:    isOpenStatus(t.Status),
+        })
+        for _, blocker := range t.BlockedBy {
+            if _, ok := tasks[blocker]; ok {
+                edges = append(edges, DependencyEdge{From: blocker, To: t.ID})
+            }
+        }
+    }
+    sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID.Hex() < nodes[j].ID.Hex() })
+    sort.Slice(edges, func(i, j int) bool {
+        if edges[i].From != edges[j].From {
+            return edges[i].From.Hex() < edges[j].From.Hex()
+        }
+        return edges[i].To.Hex() < edges[j].To.Hex()
+    })
+
+    c.JSON(200, gin.H{
+        "task_id":       task.ID,
+        "nodes":         nodes,
+        "edges":         edges,
+        "critical_path": criticalPath(task.ID, tasks),
+    })
+}
+
+// ------------------ Helper Functions ------------------
+
+// isOpenStatus reports whether a task in this status still blocks others
+func isOpenStatus(status string) bool {
+    return status != models.StatusDone && status != models.StatusCancelled
+}
+
+// Refuse to finish a task while any of its blockers is still open. Blockers
+// in the trash don't count.
+func checkBlockersResolved(ctx context.Context, task *models.Task) error {
+    if len(task.BlockedBy) == 0 {
+        return nil
+    }
+
+    cursor, err := database.GetCollection(taskCollection).Find(ctx,
+        bson.M{
+            "_id":        bson.M{"$in": task.BlockedBy},
+            "status":     bson.M{"$nin": bson.A{models.StatusDone, models.StatusCancelled}},
+            "deleted_at": nil,
+        },
+        options.Find().SetProjection(bson.M{"_id": 1}),
+    )
+    if err != nil {
+        return err
+    }
+
+    var open []struct {
+        ID primitive.ObjectID `bson:"_id"`
+    }
+    if err := cursor.All(ctx, &open); err != nil {
+        return err
+    }
+    if len(open) == 0 {
+        return nil
+    }
+
+    ids := make([]primitive.ObjectID, len(open))
+    for i, o := range open {
+        ids[i] = o.ID
+    }
+    return &taskUpdateError{409, gin.H{
+        "error":      "Task is blocked by tasks that are still open",
+        "blocked_by": ids,
+    }}
+}
+
+// Drop removed tasks from the blocker lists of the tasks they were blocking
+func releaseDependents(ctx context.Context, removed []primitive.ObjectID) error {
+    _, err := database.GetCollection(taskCollection).UpdateMany(ctx,
+        bson.M{"blocked_by": bson.M{"$in": removed}},
+        bson.M{"$pull": bson.M{"blocked_by": bson.M{"$in": removed}}},
+    )
+    return err
+}
+
+// Record a change to a task's blockers in its history
+func recordDependencyActivity(ctx context.Context, task *models.Task, blockedBy []primitive.ObjectID, by primitive.ObjectID) {
+    entry := newActivity(task.ID, by, models.ActivityTaskUpdated)
+    entry.Changes = taskFieldChanges(task, bson.M{"blocked_by": blockedBy}, nil)
+    recordActivity(ctx, entry)
+}
+
+// Find every task the given task transitively waits on, regardless of
+// visibility, so cycles through other users' tasks are caught too
+func findUpstreamIDs(ctx context.Context, taskID primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+    cursor, err := database.GetCollection(taskCollection).Aggregate(ctx, mongo.Pipeline{
+        {{Key: "$match", Value: bson.M{"_id": taskID}}},
+        {{Key: "$graphLookup", Value: bson.M{
+            "from":             taskCollection,
+            "startWith":        "$blocked_by",
+            "connectFromField": "blocked_by",
+            "connectToField":   "_id",
+            "as":               "upstream",
+            "maxDepth":         maxDependencyDepth,
+        }}},
+        {{Key: "$project", Value: bson.M{"ids": "$upstream._id"}}},
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    var result []struct {
+        IDs []primitive.ObjectID `bson:"ids"`
+    }
+    if err := cursor.All(ctx, &result); err != nil {
+        return nil, err
+    }
+
+    upstream := make(map[primitive.ObjectID]bool)
+    if len(result) > 0 {
+        for _, id := range result[0].IDs {
+            upstream[id] = true
+        }
+    }
+    return upstream, nil
+}
+
+// criticalPath returns the longest chain of open blockers ending at target,
+// ordered from the first task to finish to target itself
+func criticalPath(target primitive.ObjectID, tasks map[primitive.ObjectID]models.Task) []primitive.ObjectID {
+    longest := make(map[primitive.ObjectID][]primitive.ObjectID)
+    visiting := make(map[primitive.ObjectID]bool)
+
+    var walk func(id primitive.ObjectID) []primitive.ObjectID
+    walk = func(id primitive.ObjectID) []primitive.ObjectID {
+        if path, ok := longest[id]; ok {
+            return path
+        }
+        if visiting[id] {
+            // Stored data should be acyclic; never recurse forever if it isn't
+            return nil
+        }
+        visiting[id] = true
+
+        var best []primitive.ObjectID
+        for _, blocker := range tasks[id].BlockedBy {
+            t, ok := tasks[blocker]
+            if !ok || !isOpenStatus(t.Status) {
+                continue
+            }
+            if path := walk(blocker); len(path) > len(best) {
+                best = path
+            }
+        }
+
+        path := append(append([]primitive.ObjectID(nil), best...), id)
+        longest[id] = path
+        visiting[id] = false
+        return path
+    }
+
+    return walk(target)
+}
@@ -0,0 +1,557 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+// Limits on the number of occurrences PreviewRecurrence returns
+const (
+    defaultPreviewCount = 10
+    maxPreviewCount     = 100
+)
+
+// recurrenceInput is the schedule part of a recurring task payload
+type recurrenceInput struct {
+    Rule      string      `json:"rule"`
+    Frequency string      `json:"frequency"`
+    Timezone  string      `json:"timezone"`
+    StartsAt  *time.Time  `json:"starts_at"`
+    ExDates   []time.Time `json:"exdates"`
+}
+
+var errRecurringNotFound = errors.New("recurring task not found")
+
+// recurringTaskPayload is the body of create and update requests. Fields
+// left out keep their current value on update.
+type recurringTaskPayload struct {
+    TaskTemplate *string      `json:"task_template"`
+    Rule         *string      `json:"rule"`
+    Frequency    *string      `json:"frequency"`
+    Timezone     *string      `json:"timezone"`
+    StartsAt     *time.Time   `json:"starts_at"`
+    ExDates      *[]time.Time `json:"exdates"`
+    CatchUp      *string      `json:"catch_up"`
+}
+
+// CreateRecurringTask schedules a template to become a task on every
+// occurrence of a rule, starting with the first one from now
+func CreateRecurringTask(c *gin.Context) {
+    userID := currentUserID(c)
+
+    var payload recurringTaskPayload
+    if err := c.ShouldBindJSON(&payload); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+    if payload.TaskTemplate == nil {
+        respondWithValidationErrors(c, models.ValidationErrors{{Field: "task_template", Message: "is required"}})
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    recurring := models.RecurringTask{
+        ID:        primitive.NewObjectID(),
+        Active:    true,
+        CreatedBy: userID,
+        CreatedAt: time.Now(),
+        UpdatedAt: time.Now(),
+    }
+    recurrence, _, errs, err := payload.applyTo(ctx, &recurring)
+    if err != nil {
+        respondWithError(c, 500, "Failed to create recurring task", err)
+        return
+    }
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    next, ok := recurrence.Next(time.Now())
+    if !ok {
+        respondWithValidationErrors(c, models.ValidationErrors{{Field: "rule", Message: "has no upcoming occurrences"}})
+        return
+    }
+    recurring.NextDue = next
+
+    if _, err := database.GetCollection(recurringCollection).InsertOne(ctx, recurring); err != nil {
+        respondWithError(c, 500, "Failed to create recurring task", err)
+        return
+    }
+
+    c.JSON(201, gin.H{"message": "Recurring task created successfully", "recurring_task": recurring})
+}
+
+// GetRecurringTasks lists the caller's recurring tasks, soonest due first
+func GetRecurringTasks(c *gin.Context) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    cursor, err := database.GetCollection(recurringCollection).Find(ctx,
+        bson.M{"created_by": currentUserID(c)},
+        options.Find().SetSort(bson.D{{Key: "next_due", Value: 1}, {Key: "_id", Value: 1}}),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch recurring tasks", err)
+        return
+    }
+
+    recurringTasks := []models.RecurringTask{}
+    if err := cursor.All(ctx, &recurringTasks); err != nil {
+        respondWithError(c, 500, "Failed to fetch recurring tasks", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"recurring_tasks": recurringTasks})
+}
+
+// GetRecurringTask returns one of the caller's recurring tasks
+func GetRecurringTask(c *gin.Context) {
+    recurringID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    recurring, err := loadRecurringForUser(ctx, recurringID, currentUserID(c))
+    if err != nil {
+        respondWithRecurringLookupError(c, err)
+        return
+    }
+
+    c.JSON(200, gin.H{"recurring_task": recurring})
+}
+
+// UpdateRecurringTask changes the fields given in the payload. A new
+// schedule takes effect from its first occurrence from now.
+func UpdateRecurringTask(c *gin.Context) {
+    recurringID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var payload recurringTaskPayload
+    if err := c.ShouldBindJSON(&payload); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    recurring, err := loadRecurringForUser(ctx, recurringID, userID)
+    if err != nil {
+        respondWithRecurringLookupError(c, err)
+        return
+    }
+
+    recurrence, scheduleChanged, errs, err := payload.applyTo(ctx, recurring)
+    if err != nil {
+        respondWithError(c, 500, "Failed to update recurring task", err)
+        return
+    }
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    set := bson.M{
+        "task_template": recurring.TaskTemplate,
+        "rule":          recurring.Rule,
+        "frequency":     recurring.Frequency,
+        "timezone":      recurring.Timezone,
+        "starts_at":     recurring.StartsAt,
+        "exdates":       recurring.ExDates,
+        "catch_up":      recurring.CatchUp,
+        "updated_at":    time.Now(),
+    }
+    if scheduleChanged {
+        next, ok := recurrence.Next(time.Now())
+        if !ok {
+            respondWithValidationErrors(c, models.ValidationErrors{{Field: "rule", Message: "has no upcoming occurrences"}})
+            return
+        }
+        set["next_due"] = next
+    }
+
+    updateRecurringTask(c, recurringID, userID, bson.M{"$set": set}, "Recurring task updated successfully")
+}
+
+// DeleteRecurringTask stops and removes a recurring task. The tasks it
+// already created stay, still linked to it.
+func DeleteRecurringTask(c *gin.Context) {
+    recurringID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := database.GetCollection(recurringCollection).DeleteOne(ctx,
+        bson.M{"_id": recurringID, "created_by": currentUserID(c)},
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to delete recurring task", err)
+        return
+    }
+    if result.DeletedCount == 0 {
+        c.JSON(404, gin.H{"error": "Recurring task not found"})
+        return
+    }
+
+    c.JSON(200, gin.H{"message": "Recurring task deleted successfully"})
+}
+
+// PauseRecurringTask stops a recurring task from creating tasks until it
+// is resumed
+func PauseRecurringTask(c *gin.Context) {
+    recurringID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    updateRecurringTask(c, recurringID, currentUserID(c),
+        bson.M{"$set": bson.M{"active": false, "updated_at": time.Now()}},
+        "Recurring task paused",
+    )
+}
+
+// ResumeRecurringTask restarts a paused or deactivated recurring task from
+// its next occurrence; occurrences while it was paused are not created
+func ResumeRecurringTask(c *gin.Context) {
+    recurringID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    recurring, err := loadRecurringForUser(ctx, recurringID, userID)
+    if err != nil {
+        respondWithRecurringLookupError(c, err)
+        return
+    }
+    recurrence, err := recurrenceOf(recurring)
+    if err != nil {
+        c.JSON(409, gin.H{"error": "Recurring task has an invalid schedule: " + err.Error()})
+        return
+    }
+    next, ok := recurrence.Next(time.Now())
+    if !ok {
+        c.JSON(409, gin.H{"error": "Recurring task has no upcoming occurrences"})
+        return
+    }
+
+    updateRecurringTask(c, recurringID, userID,
+        bson.M{
+            "$set":   bson.M{"active": true, "next_due": next, "updated_at": time.Now()},
+            "$unset": bson.M{"last_error": ""},
+        },
+        "Recurring task resumed",
+    )
+}
+
+// RunRecurringTaskNow creates a task from the recurring task's template
+// right away, as an extra occurrence; the schedule is not changed
+func RunRecurringTaskNow(c *gin.Context) {
+    recurringID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    recurring, err := loadRecurringForUser(ctx, recurringID, userID)
+    if err != nil {
+        respondWithRecurringLookupError(c, err)
+        return
+    }
+    location := time.UTC
+    if recurrence, err := recurrenceOf(recurring); err == nil {
+        location = recurrence.Location()
+    }
+
+    template, err := loadTemplateForUser(ctx, recurring.TaskTemplate, userID)
+    if errors.Is(err, errTemplateNotFound) {
+        c.JSON(409, gin.H{"error": "The recurring task's template no longer exists"})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch template", err)
+        return
+    }
+
+    occurrence := time.Now().Truncate(time.Second)
+    task, err := instantiateTemplate(ctx, template, userID, templateOptions{
+        Location:   location,
+        Now:        occurrence,
+        Recurrence: &models.TaskRecurrence{ID: recurring.ID, Occurrence: occurrence},
+    })
+    if mongo.IsDuplicateKeyError(err) {
+        c.JSON(409, gin.H{"error": "A task was already created for this occurrence"})
+        return
+    }
+    if err != nil {
+        respondWithTaskCreateError(c, err)
+        return
+    }
+
+    setTaskETag(c, task)
+    c.JSON(201, gin.H{"message": "Task created from recurring task", "task": task})
+}
+
+// GetRecurringTaskInstances lists the tasks a recurring task created that
+// the caller can see, most recent occurrence first. Tasks in the trash are
+// left out.
+func GetRecurringTaskInstances(c *gin.Context) {
+    recurringID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    limit, offset, err := parseSearchPaging(c)
+    if err != nil {
+        c.JSON(400, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if _, err := loadRecurringForUser(ctx, recurringID, userID); err != nil {
+        respondWithRecurringLookupError(c, err)
+        return
+    }
+
+    filter := visibleTasksFilter(userID)
+    filter["recurrence.id"] = recurringID
+    cursor, err := database.GetCollection(taskCollection).Find(ctx, filter,
+        options.Find().
+            SetSort(bson.D{{Key: "recurrence.occurrence", Value: -1}}).
+            SetSkip(offset).
+            SetLimit(limit),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch tasks", err)
+        return
+    }
+
+    tasks := []models.Task{}
+    if err := cursor.All(ctx, &tasks); err != nil {
+        respondWithError(c, 500, "Failed to fetch tasks", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"tasks": tasks, "limit": limit, "offset": offset})
+}
+
+// PreviewRecurrence returns the next occurrences of a schedule without
+// saving anything, so a rule can be checked before it is used. The body
+// takes rule (or frequency), timezone, starts_at and exdates like a
+// recurring task, plus after (default now) and count (default 10).
+func PreviewRecurrence(c *gin.Context) {
+    var input struct {
+        recurrenceInput
+        After *time.Time `json:"after"`
+        Count int        `json:"count"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    count := input.Count
+    if count == 0 {
+        count = defaultPreviewCount
+    }
+    if count < 1 || count > maxPreviewCount {
+        respondWithValidationErrors(c, models.ValidationErrors{{
+            Field:   "count",
+            Message: fmt.Sprintf("must be between 1 and %d", maxPreviewCount),
+        }})
+        return
+    }
+
+    recurrence, errs := input.recurrenceInput.recurrence()
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    after := time.Now()
+    if input.After != nil {
+        after = *input.After
+    }
+
+    c.JSON(200, gin.H{
+        "timezone":    recurrence.Location().String(),
+        "occurrences": recurrence.Occurrences(after, count),
+    })
+}
+
+// ------------------ Helper Functions ------------------
+
+// applyTo copies the payload onto a recurring task and checks the result.
+// scheduleChanged reports whether the payload touched the schedule.
+func (p recurringTaskPayload) applyTo(ctx context.Context, recurring *models.RecurringTask) (*services.Recurrence, bool, models.ValidationErrors, error) {
+    var errs models.ValidationErrors
+
+    if p.TaskTemplate != nil {
+        templateID, err := primitive.ObjectIDFromHex(*p.TaskTemplate)
+        if err == nil {
+            _, err = loadTemplateForUser(ctx, templateID, recurring.CreatedBy)
+        }
+        if errors.Is(err, errTemplateNotFound) || errors.Is(err, primitive.ErrInvalidHex) {
+            errs = append(errs, models.FieldError{Field: "task_template", Message: "template not found"})
+        } else if err != nil {
+            return nil, false, nil, err
+        }
+        recurring.TaskTemplate = templateID
+    }
+
+    // A rule replaces a simple frequency and the other way round
+    if p.Rule != nil {
+        recurring.Rule = *p.Rule
+        if p.Frequency == nil {
+            recurring.Frequency = ""
+        }
+    }
+    if p.Frequency != nil {
+        recurring.Frequency = *p.Frequency
+        if p.Rule == nil {
+            recurring.Rule = ""
+        }
+    }
+    if p.Timezone != nil {
+        recurring.Timezone = *p.Timezone
+    }
+    if p.StartsAt != nil {
+        recurring.StartsAt = *p.StartsAt
+    }
+    if p.ExDates != nil {
+        recurring.ExDates = *p.ExDates
+    }
+    if p.CatchUp != nil {
+        valid := false
+        for _, policy := range models.CatchUpPolicies {
+            valid = valid || policy == *p.CatchUp
+        }
+        if !valid {
+            errs = append(errs, models.FieldError{Field: "catch_up", Message: "must be one of: " + strings.Join(models.CatchUpPolicies, ", ")})
+        }
+        recurring.CatchUp = *p.CatchUp
+    }
+
+    schedule := recurrenceInput{
+        Rule:      recurring.Rule,
+        Frequency: recurring.Frequency,
+        Timezone:  recurring.Timezone,
+        ExDates:   recurring.ExDates,
+    }
+    if !recurring.StartsAt.IsZero() {
+        schedule.StartsAt = &recurring.StartsAt
+    }
+    recurrence, scheduleErrs := schedule.recurrence()
+    errs = append(errs, scheduleErrs...)
+    if errs != nil {
+        return nil, false, errs, nil
+    }
+
+    scheduleChanged := p.Rule != nil || p.Frequency != nil || p.Timezone != nil || p.StartsAt != nil || p.ExDates != nil
+    return recurrence, scheduleChanged, nil, nil
+}
+
+// Fetch a recurring task owned by the user
+func loadRecurringForUser(ctx context.Context, recurringID, userID primitive.ObjectID) (*models.RecurringTask, error) {
+    var recurring models.RecurringTask
+    err := database.GetCollection(recurringCollection).
+        FindOne(ctx, bson.M{"_id": recurringID, "created_by": userID}).
+        Decode(&recurring)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, errRecurringNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &recurring, nil
+}
+
+func respondWithRecurringLookupError(c *gin.Context, err error) {
+    if errors.Is(err, errRecurringNotFound) {
+        c.JSON(404, gin.H{"error": "Recurring task not found"})
+        return
+    }
+    respondWithError(c, 500, "Failed to fetch recurring task", err)
+}
+
+// Apply an update to one of the user's recurring tasks and respond with
+// the result
+func updateRecurringTask(c *gin.Context, recurringID, userID primitive.ObjectID, update bson.M, message string) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var recurring models.RecurringTask
+    err := database.GetCollection(recurringCollection).FindOneAndUpdate(ctx,
+        bson.M{"_id": recurringID, "created_by": userID},
+        update,
+        options.FindOneAndUpdate().SetReturnDocument(options.After),
+    ).Decode(&recurring)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        c.JSON(404, gin.H{"error": "Recurring task not found"})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to update recurring task", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"message": message, "recurring_task": recurring})
+}
+
+// recurrence checks the schedule and returns it ready to evaluate
+func (in recurrenceInput) recurrence() (*services.Recurrence, models.ValidationErrors) {
+    var errs models.ValidationErrors
+
+    rule := in.Rule
+    if rule == "" {
+        if in.Frequency == "" {
+            errs = append(errs, models.FieldError{Field: "rule", Message: "rule or frequency is required"})
+        } else if frequencyRule, err := services.FrequencyRule(in.Frequency); err != nil {
+            errs = append(errs, models.FieldError{Field: "frequency", Message: "must be one of: daily, weekly, monthly"})
+        } else {
+            rule = frequencyRule
+        }
+    } else if in.Frequency != "" {
+        errs = append(errs, models.FieldError{Field: "frequency", Message: "cannot be combined with rule"})
+    }
+
+    location := time.UTC
+    if in.Timezone != "" {
+        loaded, err := time.LoadLocation(in.Timezone)
+        if err != nil {
+            errs = append(errs, models.FieldError{Field: "timezone", Message: "unknown time zone"})
+        } else {
+            location = loaded
+        }
+    }
+    if in.StartsAt == nil {
+        errs = append(errs, models.FieldError{Field: "starts_at", Message: "is required"})
+    }
+    if rule != "" {
+        if _, err := services.ParseRRule(rule, location); err != nil {
+            errs = append(errs, models.FieldError{Field: "rule", Message: err.Error()})
+        }
+    }
+    if errs != nil {
+        return nil, errs
+    }
+
+    recurrence, err := services.NewRecurrence(rule, *in.StartsAt, in.Timezone, in.ExDates)
+    if err != nil {
+        return nil, models.ValidationErrors{{Field: "rule", Message: err.Error()}}
+    }
+    return recurrence, nil
+}
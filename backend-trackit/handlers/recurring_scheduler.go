@@ -0,0 +1,199 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+const recurringCollection = "recurring_tasks"
+
+const (
+    // recurringBatchSize bounds how many recurring tasks one pass handles
+    recurringBatchSize = 100
+    // maxCatchUpOccurrences bounds how many missed occurrences one recurring
+    // task creates per pass under CatchUpAll; the rest follow on later passes
+    maxCatchUpOccurrences = 50
+    // occurrenceGracePeriod is how late an occurrence may be created and
+    // still count as on time rather than missed
+    occurrenceGracePeriod = 15 * time.Minute
+)
+
+// RunDueRecurringTasks creates the tasks for every active recurring task
+// whose next occurrence is due at now, then moves NextDue past it. Each
+// occurrence creates at most one task, so passes that overlap or are
+// retried after a crash do not create duplicates. It returns the number of
+// tasks created.
+func RunDueRecurringTasks(ctx context.Context, now time.Time) (int, error) {
+    cursor, err := database.GetCollection(recurringCollection).Find(ctx,
+        bson.M{"active": true, "next_due": bson.M{"$lte": now}},
+        options.Find().SetSort(bson.D{{Key: "next_due", Value: 1}}).SetLimit(recurringBatchSize),
+    )
+    if err != nil {
+        return 0, err
+    }
+
+    var due []models.RecurringTask
+    if err := cursor.All(ctx, &due); err != nil {
+        return 0, err
+    }
+
+    created := 0
+    for i := range due {
+        if err := ctx.Err(); err != nil {
+            return created, err
+        }
+        n, err := materializeRecurringTask(ctx, &due[i], now)
+        created += n
+        if err != nil && ctx.Err() == nil {
+            // One broken recurring task must not hold up the others
+            log.Printf("Recurring task %s failed: %v", due[i].ID.Hex(), err)
+        }
+    }
+    return created, nil
+}
+
+// ------------------ Helper Functions ------------------
+
+// Create the due occurrences of one recurring task and advance it
+func materializeRecurringTask(ctx context.Context, recurring *models.RecurringTask, now time.Time) (int, error) {
+    recurrence, err := recurrenceOf(recurring)
+    if err != nil {
+        return 0, deactivateRecurringTask(ctx, recurring.ID, err.Error())
+    }
+    occurrences, next, err := dueOccurrences(recurring, recurrence, now)
+    if err != nil {
+        return 0, deactivateRecurringTask(ctx, recurring.ID, err.Error())
+    }
+
+    template, err := loadTemplateForUser(ctx, recurring.TaskTemplate, recurring.CreatedBy)
+    if errors.Is(err, errTemplateNotFound) {
+        return 0, deactivateRecurringTask(ctx, recurring.ID, "template not found")
+    }
+    if err != nil {
+        return 0, err
+    }
+
+    created := 0
+    lastCreated := recurring.LastCreated
+    var createErr error
+    for _, occurrence := range occurrences {
+        if createErr = ctx.Err(); createErr != nil {
+            next = occurrence
+            break
+        }
+
+        _, err := instantiateTemplate(ctx, template, recurring.CreatedBy, templateOptions{
+            Location:   recurrence.Location(),
+            Now:        occurrence,
+            Recurrence: &models.TaskRecurrence{ID: recurring.ID, Occurrence: occurrence},
+        })
+        if err != nil && !mongo.IsDuplicateKeyError(err) {
+            // A template that no longer makes a valid task will not fix
+            // itself; anything else is retried on the next pass
+            var updateErr *taskUpdateError
+            if errors.As(err, &updateErr) {
+                return created, deactivateRecurringTask(ctx, recurring.ID, fmt.Sprintf("%v", updateErr.Body))
+            }
+            next, createErr = occurrence, err
+            break
+        }
+        if err == nil {
+            created++
+        }
+        lastCreated = occurrence
+    }
+
+    // Only advance from the NextDue this pass started with, in case another
+    // pass got there first. A rule that has run out is finished.
+    set := bson.M{"next_due": next}
+    if next.IsZero() {
+        set = bson.M{"active": false}
+    }
+    if !lastCreated.IsZero() {
+        set["last_created"] = lastCreated
+    }
+    if _, err := database.GetCollection(recurringCollection).UpdateOne(context.Background(),
+        bson.M{"_id": recurring.ID, "next_due": recurring.NextDue},
+        bson.M{"$set": set},
+    ); err != nil {
+        return created, err
+    }
+    return created, createErr
+}
+
+// dueOccurrences lists the occurrences to create at now under the
+// recurring task's catch-up policy, and the NextDue that follows them,
+// which is zero once the rule has run out
+func dueOccurrences(recurring *models.RecurringTask, recurrence *services.Recurrence, now time.Time) ([]time.Time, time.Time, error) {
+    policy := recurring.CatchUp
+    if policy == "" {
+        policy = models.CatchUpLatest
+    }
+
+    due := []time.Time{recurring.NextDue}
+    var next time.Time
+    recurrence.Each(recurring.NextDue, func(occurrence time.Time) bool {
+        if occurrence.After(now) || (policy == models.CatchUpAll && len(due) == maxCatchUpOccurrences) {
+            next = occurrence
+            return false
+        }
+        due = append(due, occurrence)
+        return true
+    })
+
+    switch policy {
+    case models.CatchUpAll:
+        return due, next, nil
+    case models.CatchUpLatest:
+        return due[len(due)-1:], next, nil
+    case models.CatchUpSkip:
+        var onTime []time.Time
+        for _, occurrence := range due {
+            if now.Sub(occurrence) <= occurrenceGracePeriod {
+                onTime = append(onTime, occurrence)
+            }
+        }
+        return onTime, next, nil
+    }
+    return nil, time.Time{}, fmt.Errorf("unknown catch-up policy %q", policy)
+}
+
+// recurrenceOf evaluates a recurring task's rule, or its simple frequency
+// when it has no rule, from its first occurrence
+func recurrenceOf(recurring *models.RecurringTask) (*services.Recurrence, error) {
+    rule := recurring.Rule
+    if rule == "" {
+        frequencyRule, err := services.FrequencyRule(recurring.Frequency)
+        if err != nil {
+            return nil, err
+        }
+        rule = frequencyRule
+    }
+    start := recurring.StartsAt
+    if start.IsZero() {
+        start = recurring.NextDue
+    }
+    return services.NewRecurrence(rule, start, recurring.Timezone, recurring.ExDates)
+}
+
+// Stop a recurring task the scheduler cannot run, recording why
+func deactivateRecurringTask(ctx context.Context, recurringID primitive.ObjectID, reason string) error {
+    log.Printf("Deactivating recurring task %s: %s", recurringID.Hex(), reason)
+    _, err := database.GetCollection(recurringCollection).UpdateOne(ctx,
+        bson.M{"_id": recurringID},
+        bson.M{"$set": bson.M{"active": false, "last_error": reason}},
+    )
+    return err
+}
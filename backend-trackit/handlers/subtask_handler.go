@@ -0,0 +1,342 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "log"
+    "math"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+// maxTaskDepth bounds how deeply tasks can nest, which also bounds every
+// walk up or down the hierarchy
+const maxTaskDepth = 10
+
+// What happens to the subtasks of a deleted task
+const (
+    childrenOrphan   = "orphan"   // subtasks become top-level tasks
+    childrenReparent = "reparent" // subtasks move up to the deleted task's parent
+    childrenDelete   = "delete"   // subtasks are deleted too
+)
+
+// GetSubtasks lists the direct subtasks of a task that the user can see
+func GetSubtasks(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, _, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    cursor, err := database.GetCollection(taskCollection).Find(ctx,
+        bson.M{"$and": []bson.M{{"parent_id": task.ID}, visibleTasksFilter(userID)}},
+        options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch subtasks", err)
+        return
+    }
+
+    subtasks := []models.Task{}
+    if err := cursor.All(ctx, &subtasks); err != nil {
+        respondWithError(c, 500, "Failed to fetch subtasks", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"parent": task.ID, "subtasks": subtasks, "rollup": task.Subtasks})
+}
+
+// SetTaskParent moves a task under another task, or back to the top level
+// when parent_id is null
+func SetTaskParent(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        ParentID *string `json:"parent_id"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    payload := map[string]interface{}{"parent_id": nil}
+    if input.ParentID != nil {
+        payload["parent_id"] = *input.ParentID
+    }
+    validated, errs := models.ValidateTaskInput(payload, true)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+
+    change := newTaskChange(task, role, userID, validated)
+    change.Version = version
+    if err := applyTaskChange(ctx, change); err != nil {
+        respondWithTaskUpdateError(c, err)
+        return
+    }
+
+    c.JSON(200, gin.H{"message": "Task moved successfully", "parent_id": validated.Set["parent_id"]})
+}
+
+// ------------------ Helper Functions ------------------
+
+// Check that parentID can become the parent of taskID: the user must see
+// the parent and the move must not create a cycle or push the task or any
+// of its subtasks deeper than maxTaskDepth
+func validateParent(ctx context.Context, taskID, parentID, userID primitive.ObjectID) error {
+    if parentID == taskID {
+        return validationFailed(models.ValidationErrors{{Field: "parent_id", Message: "a task cannot be its own parent"}})
+    }
+
+    parent, _, err := loadTaskForUser(ctx, parentID, userID)
+    if errors.Is(err, errTaskNotFound) {
+        return validationFailed(models.ValidationErrors{{Field: "parent_id", Message: "parent task not found"}})
+    }
+    if err != nil {
+        return err
+    }
+
+    tooDeep := validationFailed(models.ValidationErrors{{Field: "parent_id", Message: "tasks cannot be nested that deeply"}})
+    collection := database.GetCollection(taskCollection)
+    parentDepth := 0
+    ancestor := parent.ParentID
+    for ancestor != nil {
+        if *ancestor == taskID {
+            return &taskUpdateError{409, gin.H{"error": "Moving the task there would create a cycle"}}
+        }
+        parentDepth++
+        if parentDepth >= maxTaskDepth {
+            return tooDeep
+        }
+
+        var next struct {
+            ParentID *primitive.ObjectID `bson:"parent_id"`
+        }
+        err := collection.FindOne(ctx, bson.M{"_id": *ancestor}, options.FindOne().SetProjection(bson.M{"parent_id": 1})).Decode(&next)
+        if errors.Is(err, mongo.ErrNoDocuments) {
+            break
+        }
+        if err != nil {
+            return err
+        }
+        ancestor = next.ParentID
+    }
+
+    // The task's own subtasks move down with it
+    height, err := subtreeHeight(ctx, taskID)
+    if err != nil {
+        return err
+    }
+    if parentDepth+1+height > maxTaskDepth {
+        return tooDeep
+    }
+    return nil
+}
+
+// Count the levels of subtasks below a task, 0 when it has none. Trashed
+// subtasks count too, since restoring them brings them back at their level.
+func subtreeHeight(ctx context.Context, taskID primitive.ObjectID) (int, error) {
+    cursor, err := database.GetCollection(taskCollection).Aggregate(ctx, mongo.Pipeline{
+        {{Key: "$match", Value: bson.M{"_id": taskID}}},
+        {{Key: "$graphLookup", Value: bson.M{
+            "from":             taskCollection,
+            "startWith":        "$_id",
+            "connectFromField": "_id",
+            "connectToField":   "parent_id",
+            "as":               "descendants",
+            "maxDepth":         maxTaskDepth,
+            "depthField":       "level",
+        }}},
+        {{Key: "$project", Value: bson.M{"deepest": bson.M{"$max": "$descendants.level"}}}},
+    })
+    if err != nil {
+        return 0, err
+    }
+
+    var result []struct {
+        Deepest *int `bson:"deepest"`
+    }
+    if err := cursor.All(ctx, &result); err != nil {
+        return 0, err
+    }
+    if len(result) == 0 || result[0].Deepest == nil {
+        return 0, nil
+    }
+    // depthField counts from 0 for direct subtasks
+    return *result[0].Deepest + 1, nil
+}
+
+// Recompute the completion roll-up of a parent and each of its ancestors.
+// A child counts as 100% when done, otherwise its own roll-up when it has
+// subtasks, otherwise its progress field. Cancelled and trashed children are
+// ignored.
+func refreshSubtaskRollups(ctx context.Context, parentID *primitive.ObjectID) {
+    collection := database.GetCollection(taskCollection)
+
+    for depth := 0; parentID != nil && depth < maxTaskDepth; depth++ {
+        cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+            {{Key: "$match", Value: bson.M{
+                "parent_id":  *parentID,
+                "status":     bson.M{"$ne": models.StatusCancelled},
+                "deleted_at": nil,
+            }}},
+            {{Key: "$group", Value: bson.M{
+                "_id":   nil,
+                "total": bson.M{"$sum": 1},
+                "done": bson.M{"$sum": bson.M{
+                    "$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.StatusDone}}, 1, 0},
+                }},
+                "progress": bson.M{"$avg": bson.M{
+                    "$cond": bson.A{
+                        bson.M{"$eq": bson.A{"$status", models.StatusDone}},
+                        100,
+                        bson.M{"$ifNull": bson.A{"$subtasks.progress", bson.M{"$ifNull": bson.A{"$progress", 0}}}},
+                    },
+                }},
+            }}},
+        })
+        if err != nil {
+            log.Printf("Failed to compute subtask roll-up for %s: %v", parentID.Hex(), err)
+            return
+        }
+
+        var totals []struct {
+            Total    int     `bson:"total"`
+            Done     int     `bson:"done"`
+            Progress float64 `bson:"progress"`
+        }
+        if err := cursor.All(ctx, &totals); err != nil {
+            log.Printf("Failed to compute subtask roll-up for %s: %v", parentID.Hex(), err)
+            return
+        }
+
+        update := bson.M{"$unset": bson.M{"subtasks": ""}}
+        if len(totals) > 0 {
+            update = bson.M{"$set": bson.M{"subtasks": models.SubtaskRollup{
+                Total:    totals[0].Total,
+                Done:     totals[0].Done,
+                Progress: int(math.Round(totals[0].Progress)),
+            }}}
+        }
+
+        var parent struct {
+            ParentID *primitive.ObjectID `bson:"parent_id"`
+        }
+        err = collection.FindOneAndUpdate(ctx, bson.M{"_id": *parentID}, update,
+            options.FindOneAndUpdate().SetProjection(bson.M{"parent_id": 1}),
+        ).Decode(&parent)
+        if err != nil {
+            if !errors.Is(err, mongo.ErrNoDocuments) {
+                log.Printf("Failed to save subtask roll-up for %s: %v", parentID.Hex(), err)
+            }
+            return
+        }
+        parentID = parent.ParentID
+    }
+}
+
+// Apply the chosen cascade mode to the subtasks of a task that was moved to
+// the trash. In delete mode its descendants are trashed along with it.
+func detachSubtasks(ctx context.Context, task *models.Task, mode string, userID primitive.ObjectID, deletedAt time.Time) error {
+    collection := database.GetCollection(taskCollection)
+    trashed := []primitive.ObjectID{task.ID}
+
+    if mode == childrenDelete {
+        descendants, err := findDescendantIDs(ctx, task.ID, userID)
+        if err != nil {
+            return err
+        }
+        if len(descendants) > 0 {
+            trash := trashUpdate(userID, deletedAt)
+            trash["$set"].(bson.M)["deleted_with"] = task.ID
+            bumpVersion(trash)
+            if _, err := collection.UpdateMany(ctx,
+                bson.M{"_id": bson.M{"$in": descendants}, "created_by": userID, "deleted_at": nil},
+                trash,
+            ); err != nil {
+                return err
+            }
+            deleted := make([]models.Activity, len(descendants))
+            for i, id := range descendants {
+                deleted[i] = newActivity(id, userID, models.ActivityTaskDeleted)
+            }
+            recordActivity(ctx, deleted...)
+            trashed = append(trashed, descendants...)
+        }
+    }
+
+    // Live tasks left under a trashed task either move up or become
+    // top-level; trashed ones keep their parent so they can be restored
+    update := bson.M{"$unset": bson.M{"parent_id": ""}}
+    if mode == childrenReparent && task.ParentID != nil {
+        update = bson.M{"$set": bson.M{"parent_id": *task.ParentID}}
+    }
+    bumpVersion(update)
+    _, err := collection.UpdateMany(ctx, bson.M{"parent_id": bson.M{"$in": trashed}, "deleted_at": nil}, update)
+    return err
+}
+
+// Find the IDs of every descendant of a task that the user created and that
+// isn't already in the trash
+func findDescendantIDs(ctx context.Context, taskID, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+    cursor, err := database.GetCollection(taskCollection).Aggregate(ctx, mongo.Pipeline{
+        {{Key: "$match", Value: bson.M{"_id": taskID}}},
+        {{Key: "$graphLookup", Value: bson.M{
+            "from":                    taskCollection,
+            "startWith":               "$_id",
+            "connectFromField":        "_id",
+            "connectToField":          "parent_id",
+            "as":                      "descendants",
+            "maxDepth":                maxTaskDepth,
+            "restrictSearchWithMatch": bson.M{"created_by": userID, "deleted_at": nil},
+        }}},
+        {{Key: "$project", Value: bson.M{"ids": "$descendants._id"}}},
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    var result []struct {
+        IDs []primitive.ObjectID `bson:"ids"`
+    }
+    if err := cursor.All(ctx, &result); err != nil {
+        return nil, err
+    }
+    if len(result) == 0 {
+        return nil, nil
+    }
+    return result[0].IDs, nil
+}
+
+func isValidChildrenMode(mode string) bool {
+    return mode == childrenOrphan || mode == childrenReparent || mode == childrenDelete
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+// errTaskNotFound is returned when a task doesn't exist or the caller has no
+// role on it. Both cases answer 404 so task IDs can't be probed.
+var errTaskNotFound = errors.New("task not found")
+
+// currentUserID returns the authenticated user's ID set by AuthMiddleware
+func currentUserID(c *gin.Context) primitive.ObjectID {
+    userID, _ := primitive.ObjectIDFromHex(c.GetString("userId"))
+    return userID
+}
+
+// loadTaskForUser fetches a task together with the caller's role on it.
+// Tasks in the trash are treated as missing.
+func loadTaskForUser(ctx context.Context, taskID, userID primitive.ObjectID) (*models.Task, services.TaskRole, error) {
+    var task models.Task
+    err := database.GetCollection(taskCollection).FindOne(ctx, bson.M{"_id": taskID, "deleted_at": nil}).Decode(&task)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, services.TaskRoleNone, errTaskNotFound
+    }
+    if err != nil {
+        return nil, services.TaskRoleNone, err
+    }
+
+    role := services.RoleForTask(task, userID)
+    if !role.CanView() {
+        return nil, services.TaskRoleNone, errTaskNotFound
+    }
+    return &task, role, nil
+}
+
+// taskAccessFilter matches the task only while the user still holds the
+// role, so a write can't race with a reassignment that revokes it
+func taskAccessFilter(taskID, userID primitive.ObjectID, role services.TaskRole) bson.M {
+    filter := bson.M{"_id": taskID}
+    switch role {
+    case services.TaskRoleCreator:
+        filter["created_by"] = userID
+    case services.TaskRoleAssignee:
+        filter["assigned_to"] = userID
+    default:
+        // Matches nothing
+        filter["_id"] = primitive.NilObjectID
+    }
+    return filter
+}
+
+// respondWithTaskLookupError maps a loadTaskForUser error to a response
+func respondWithTaskLookupError(c *gin.Context, err error) {
+    if errors.Is(err, errTaskNotFound) {
+        c.JSON(404, gin.H{"error": "Task not found"})
+        return
+    }
+    respondWithError(c, 500, "Failed to fetch task", err)
+}
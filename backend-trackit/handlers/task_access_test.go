@@ -0,0 +1,103 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+// The lookup each test mocks is the only database call made before the
+// role check answers, so one canned response per request is enough.
+func TestTaskRoleResponses(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+    creator, assignee, outsider := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+    task := models.Task{
+        ID:         primitive.NewObjectID(),
+        Title:      "Write the report",
+        Status:     models.StatusTodo,
+        Priority:   models.PriorityMedium,
+        AssignedTo: []primitive.ObjectID{assignee},
+        CreatedBy:  creator,
+        CreatedAt:  time.Now(),
+        UpdatedAt:  time.Now(),
+        Version:    1,
+    }
+
+    tests := []struct {
+        name    string
+        handler gin.HandlerFunc
+        method  string
+        body    string
+        userID  primitive.ObjectID
+        found   bool
+        status  int
+        fields  []string
+    }{
+        {"non-member update", UpdateTask, "PUT", `{"title":"Mine now"}`, outsider, true, 404, nil},
+        {"non-member status update", UpdateTask, "PUT", `{"status":"done"}`, outsider, true, 404, nil},
+        {"missing task update", UpdateTask, "PUT", `{"title":"Mine now"}`, creator, false, 404, nil},
+        {"assignee edits title", UpdateTask, "PUT", `{"title":"Mine now"}`, assignee, true, 403, []string{"title"}},
+        {"assignee edits several fields", UpdateTask, "PUT", `{"title":"Mine now","priority":"high","progress":50}`, assignee, true, 403, []string{"priority", "title"}},
+        {"non-member delete", DeleteTask, "DELETE", "", outsider, true, 404, nil},
+        {"assignee delete", DeleteTask, "DELETE", "", assignee, true, 403, nil},
+    }
+    for _, tt := range tests {
+        mt.Run(tt.name, func(mt *mtest.T) {
+            database.DB = mt.DB
+
+            var batch []bson.D
+            if tt.found {
+                doc, err := bson.Marshal(task)
+                if err != nil {
+                    t.Fatal(err)
+                }
+                var d bson.D
+                if err := bson.Unmarshal(doc, &d); err != nil {
+                    t.Fatal(err)
+                }
+                batch = append(batch, d)
+            }
+            mt.AddMockResponses(mtest.CreateCursorResponse(0, "trackit."+taskCollection, mtest.FirstBatch, batch...))
+
+            w := httptest.NewRecorder()
+            c, _ := gin.CreateTestContext(w)
+            c.Request = httptest.NewRequest(tt.method, "/api/tasks/"+task.ID.Hex(), strings.NewReader(tt.body))
+            c.Request.Header.Set("Content-Type", "application/json")
+            c.Params = gin.Params{{Key: "id", Value: task.ID.Hex()}}
+            c.Set("userId", tt.userID.Hex())
+
+            tt.handler(c)
+
+            if w.Code != tt.status {
+                mt.Fatalf("status = %d, want %d; body %s", w.Code, tt.status, w.Body.String())
+            }
+            if tt.fields != nil {
+                var body struct {
+                    Fields []string `json:"fields"`
+                }
+                if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+                    mt.Fatal(err)
+                }
+                if strings.Join(body.Fields, ",") != strings.Join(tt.fields, ",") {
+                    mt.Errorf("denied fields = %v, want %v", body.Fields, tt.fields)
+                }
+            }
+            if tt.status == http.StatusNotFound && strings.Contains(w.Body.String(), task.Title) {
+                mt.Errorf("404 response leaks the task: %s", w.Body.String())
+            }
+        })
+    }
+}
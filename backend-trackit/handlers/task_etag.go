@@ -0,0 +1,67 @@
+package handlers
+
+import (
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+
+    "backend-trackit/config"
+    "backend-trackit/models"
+)
+
+// taskETag is the entity tag for a version of a task
+func taskETag(task *models.Task) string {
+    return `"` + strconv.FormatInt(task.Version, 10) + `"`
+}
+
+// setTaskETag sends the task's version as the ETag response header
+func setTaskETag(c *gin.Context, task *models.Task) {
+    c.Header("ETag", taskETag(task))
+}
+
+// checkIfMatch compares the If-Match header with the task's version. It
+// returns the version the write must be conditioned on, or nil when the
+// request gives no version to check against. ok is false when the request
+// was refused and a response has already been sent: 428 when strict mode
+// requires the header and it is missing, 412 when it names another version.
+func checkIfMatch(c *gin.Context, task *models.Task) (expected *int64, ok bool) {
+    header := strings.TrimSpace(c.GetHeader("If-Match"))
+    if header == "" {
+        if config.RequireIfMatch() {
+            c.JSON(428, gin.H{"error": "If-Match header is required", "current_version": task.Version})
+            return nil, false
+        }
+        return nil, true
+    }
+    if header == "*" {
+        return nil, true
+    }
+
+    current := taskETag(task)
+    for _, tag := range strings.Split(header, ",") {
+        tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+        if tag == current {
+            version := task.Version
+            return &version, true
+        }
+    }
+
+    respondWithVersionConflict(c, task)
+    return nil, false
+}
+
+// respondWithVersionConflict sends 412 with the task as it currently is, so
+// the client can merge its changes and retry
+func respondWithVersionConflict(c *gin.Context, task *models.Task) {
+    setTaskETag(c, task)
+    c.JSON(412, versionConflictBody(task))
+}
+
+func versionConflictBody(task *models.Task) gin.H {
+    return gin.H{
+        "error":           "Task was modified by someone else",
+        "current_version": task.Version,
+        "task":            task,
+    }
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
     "context"
+    "errors"
     "log"
     "os"
     "time"
@@ -11,49 +12,90 @@ import (
     "github.com/gin-gonic/gin"
     "go.mongodb.org/mongo-driver/bson"
     "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
 
     "backend-trackit/models"
     "backend-trackit/database"
     "backend-trackit/services"
 )
 
-// CreateTask handles creating a new task
+// Collection names
+const taskCollection = "tasks"
+
+// CreateTask handles creating a new task. The caller always becomes the
+// task's creator, whatever the payload says.
 func CreateTask(c *gin.Context) {
-    var task models.Task
-    if err := c.ShouldBindJSON(&task); err != nil {
+    var payload map[string]interface{}
+    if err := c.ShouldBindJSON(&payload); err != nil {
         respondWithError(c, 400, "Invalid request payload", err)
         return
     }
 
-    userID, _ := primitive.ObjectIDFromHex(c.GetString("user_id"))
-    task.ID = primitive.NewObjectID()
-    task.CreatedBy = userID
-    task.CreatedAt = time.Now()
-    task.UpdatedAt = time.Now()
+    input, errs := models.ValidateTaskInput(payload, false)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
 
-    if err := insertTask(task); err != nil {
-        respondWithError(c, 500, "Failed to create task", err)
+    task, err := createTask(context.Background(), input, currentUserID(c), nil)
+    if err != nil {
+        respondWithTaskCreateError(c, err)
         return
     }
+    setTaskETag(c, task)
     c.JSON(201, gin.H{"message": "Task created successfully", "task": task})
 }
 
-// GetTasks retrieves all tasks for a user
+// GetTasks retrieves a filtered, sorted page of the user's tasks
 func GetTasks(c *gin.Context) {
-    userID, _ := primitive.ObjectIDFromHex(c.GetString("user_id"))
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    customFields, err := loadCustomFields(ctx)
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch custom fields", err)
+        return
+    }
+
+    query, err := parseTaskQuery(c.Request.URL.Query(), currentUserID(c), customFields)
+    if err != nil {
+        c.JSON(400, gin.H{"error": err.Error()})
+        return
+    }
 
-    tasks, err := fetchUserTasks(userID)
+    tasks, nextCursor, err := fetchUserTasks(query)
     if err != nil {
         respondWithError(c, 500, "Failed to fetch tasks", err)
         return
     }
+    for i := range tasks {
+        tasks[i].FillChecklistRatio()
+    }
+
+    c.JSON(200, gin.H{"tasks": tasks, "next_cursor": nextCursor})
+}
+
+// GetTask retrieves a single task the user can see
+func GetTask(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    task, _, err := loadTaskForUser(context.Background(), taskID, currentUserID(c))
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
 
-    c.JSON(200, gin.H{"tasks": tasks})
+    task.FillChecklistRatio()
+    setTaskETag(c, task)
+    c.JSON(200, gin.H{"task": task})
 }
 
-// UpdateTask modifies an existing task
+// UpdateTask modifies an existing task. Creators may change any field,
+// assignees only the fields services.TaskRole allows them. An If-Match
+// header makes the update conditional on the task's version.
 func UpdateTask(c *gin.Context) {
     taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
     var updateData map[string]interface{}
 
     if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -61,84 +103,224 @@ func UpdateTask(c *gin.Context) {
         return
     }
 
-    // Translate JSON field names to BSON using model tags
-    jsonToBSON := getJSONToBSONMap(models.Task{})
-    bsonUpdateData := make(bson.M)
-
-    for jsonKey, value := range updateData {
-        bsonKey, ok := jsonToBSON[jsonKey]
-        if !ok {
-            // Skip fields not present in the model
-            continue
-        }
-        bsonUpdateData[bsonKey] = value
+    input, errs := models.ValidateTaskInput(updateData, true)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
     }
 
-    // Remove non-updatable fields
-    delete(bsonUpdateData, "_id")
-    delete(bsonUpdateData, "created_by")
-    delete(bsonUpdateData, "created_at")
-
-    // Set updated_at to current time
-    bsonUpdateData["updated_at"] = time.Now()
-
-    if modifiedCount, err := updateTask(taskID, bsonUpdateData); err != nil {
-        respondWithError(c, 500, "Failed to update task", err)
+    task, role, err := loadTaskForUser(context.Background(), taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
         return
-    } else if modifiedCount == 0 {
-        c.JSON(404, gin.H{"error": "Task not found"})
+    }
+
+    change := newTaskChange(task, role, userID, input)
+    change.Version = version
+    if err := applyTaskChange(context.Background(), change); err != nil {
+        respondWithTaskUpdateError(c, err)
         return
     }
 
-    c.JSON(200, gin.H{"message": "Task updated successfully"})
+    respondWithUpdatedTask(c, task.ID, userID, "Task updated successfully")
 }
 
-// DeleteTask removes a task if the user is authorized
+// DeleteTask moves a task to the trash if the user is authorized. The
+// children query parameter decides what happens to its subtasks: orphan
+// (default), reparent or delete, which trashes them along with the task.
 func DeleteTask(c *gin.Context) {
     taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
-    userID, _ := primitive.ObjectIDFromHex(c.GetString("user_id"))
+    userID := currentUserID(c)
+
+    mode := c.DefaultQuery("children", childrenOrphan)
+    if !isValidChildrenMode(mode) {
+        c.JSON(400, gin.H{"error": "children must be one of: orphan, reparent, delete"})
+        return
+    }
 
-    if deletedCount, err := deleteTask(taskID, userID); err != nil {
+    task, role, err := loadTaskForUser(context.Background(), taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    if !role.CanDelete() {
+        c.JSON(403, gin.H{"error": "Only the task creator can delete this task"})
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+
+    filter := taskAccessFilter(taskID, userID, role)
+    if version != nil {
+        filter["version"] = *version
+    }
+
+    now := time.Now()
+    if matchedCount, err := trashTask(filter, userID, now); err != nil {
         respondWithError(c, 500, "Failed to delete task", err)
         return
-    } else if deletedCount == 0 {
-        c.JSON(404, gin.H{"error": "Task not found or unauthorized"})
+    } else if matchedCount == 0 {
+        respondWithTaskUpdateError(c, staleTaskError(context.Background(), task, userID))
+        return
+    }
+
+    recordActivity(context.Background(), newActivity(task.ID, userID, models.ActivityTaskDeleted))
+
+    if err := detachSubtasks(context.Background(), task, mode, userID, now); err != nil {
+        respondWithError(c, 500, "Failed to detach related tasks", err)
         return
     }
+    refreshSubtaskRollups(context.Background(), task.ParentID)
 
-    c.JSON(200, gin.H{"message": "Task deleted successfully"})
+    c.JSON(200, gin.H{"message": "Task moved to trash"})
 }
 
 // ------------------ Helper Functions ------------------
 
+// Respond to a successful update with the task as it is now and its ETag
+func respondWithUpdatedTask(c *gin.Context, taskID, userID primitive.ObjectID, message string) {
+    task, _, err := loadTaskForUser(context.Background(), taskID, userID)
+    if err != nil {
+        // The update went through; the user may just have lost access since
+        c.JSON(200, gin.H{"message": message})
+        return
+    }
+    task.FillChecklistRatio()
+    setTaskETag(c, task)
+    c.JSON(200, gin.H{"message": message, "task": task})
+}
+
+// Create a task from validated input on behalf of userID, applying the
+// workflow's initial state and the other defaults. recurrence is set for
+// tasks a recurring task creates.
+func createTask(ctx context.Context, input *models.TaskInput, userID primitive.ObjectID, recurrence *models.TaskRecurrence) (*models.Task, error) {
+    workflow, err := loadWorkflow(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if status, ok := input.Set["status"].(string); ok && !workflow.HasState(status) {
+        return nil, validationFailed(models.ValidationErrors{{
+            Field:   "status",
+            Message: "must be one of the workflow states: " + strings.Join(workflow.States, ", "),
+        }})
+    }
+
+    if assignees, ok := input.Set["assigned_to"].([]primitive.ObjectID); ok {
+        if errs, err := validateAssigneesExist(ctx, assignees); err != nil {
+            return nil, err
+        } else if errs != nil {
+            return nil, validationFailed(errs)
+        }
+    }
+
+    task := models.Task{
+        Status:   workflow.InitialState,
+        Priority: models.PriorityMedium,
+    }
+    set, _ := taskInputToBSON(input)
+    if raw, ok := set["custom_fields"].(map[string]interface{}); ok {
+        values, _, err := resolveCustomFieldValues(ctx, raw)
+        if err != nil {
+            return nil, err
+        }
+        set["custom_fields"] = values
+    }
+    if err := applyBSONFields(&task, set); err != nil {
+        return nil, err
+    }
+    task.PriorityRank = models.PriorityRank(task.Priority)
+    task.StatusRank = models.StatusRank(task.Status)
+
+    task.ID = primitive.NewObjectID()
+    if task.Rank, err = rankAtColumnEnd(ctx, task.Status, task.ID); err != nil {
+        return nil, err
+    }
+    task.Version = 1
+    task.CreatedBy = userID
+    task.CreatedAt = time.Now()
+    task.UpdatedAt = time.Now()
+    task.Recurrence = recurrence
+    if task.Status == models.StatusDone {
+        task.CompletedAt = &task.CreatedAt
+    }
+
+    if task.ParentID != nil {
+        if err := validateParent(ctx, task.ID, *task.ParentID, task.CreatedBy); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := insertTask(task); err != nil {
+        return nil, err
+    }
+    recordActivity(ctx, newActivity(task.ID, task.CreatedBy, models.ActivityTaskCreated))
+    notifyAssignmentChange(task, nil, task.CreatedBy)
+    refreshSubtaskRollups(ctx, task.ParentID)
+    return &task, nil
+}
+
+// respondWithTaskCreateError sends the response for a createTask error
+func respondWithTaskCreateError(c *gin.Context, err error) {
+    var updateErr *taskUpdateError
+    if errors.As(err, &updateErr) {
+        c.JSON(updateErr.Status, updateErr.Body)
+        return
+    }
+    respondWithError(c, 500, "Failed to create task", err)
+}
+
 // Insert a task into the database
 func insertTask(task models.Task) error {
-    collection := database.Client.Database("data_trackit").Collection("tasks")
+    collection := database.GetCollection(taskCollection)
     _, err := collection.InsertOne(context.Background(), task)
     return err
 }
 
-// Fetch tasks for a user
-func fetchUserTasks(userID primitive.ObjectID) ([]models.Task, error) {
-    collection := database.Client.Database("data_trackit").Collection("tasks")
-    cursor, err := collection.Find(context.Background(), bson.M{
-        "$or": []bson.M{
-            {"created_by": userID},
-            {"assigned_to": userID},
-        },
-    })
+// Fetch one page of tasks matching the query, plus the cursor for the next page
+func fetchUserTasks(query *taskQuery) ([]models.Task, *string, error) {
+    collection := database.GetCollection(taskCollection)
+    opts := options.Find().
+        SetSort(query.sort()).
+        SetLimit(query.Limit + 1)
+
+    cursor, err := collection.Find(context.Background(), query.Filter, opts)
     if err != nil {
-        return nil, err
+        return nil, nil, err
     }
 
-    var tasks []models.Task
-    if err := cursor.All(context.Background(), &tasks); err != nil {
-        return nil, err
+    var docs []bson.Raw
+    if err := cursor.All(context.Background(), &docs); err != nil {
+        return nil, nil, err
+    }
+
+    var nextCursor *string
+    if int64(len(docs)) > query.Limit {
+        docs = docs[:query.Limit]
+        encoded, err := encodeTaskCursor(query, docs[len(docs)-1])
+        if err != nil {
+            return nil, nil, err
+        }
+        nextCursor = &encoded
+    }
+
+    tasks := make([]models.Task, 0, len(docs))
+    for _, doc := range docs {
+        var task models.Task
+        if err := bson.Unmarshal(doc, &task); err != nil {
+            return nil, nil, err
+        }
+        tasks = append(tasks, task)
     }
-    return tasks, nil
+    return tasks, nextCursor, nil
 }
 
-// Update a task in the database
+// Build a JSON field name to BSON field name map from a model's struct tags
 func getJSONToBSONMap(model interface{}) map[string]string {
     result := make(map[string]string)
     t := reflect.TypeOf(model)
@@ -151,30 +333,67 @@ func getJSONToBSONMap(model interface{}) map[string]string {
         }
         jsonName := strings.Split(jsonTag, ",")[0]
         bsonName := strings.Split(bsonTag, ",")[0]
+        if jsonName == "-" {
+            continue
+        }
         result[jsonName] = bsonName
     }
     return result
 }
 
-// Update a task in the database using bson.M
-func updateTask(taskID primitive.ObjectID, updateData bson.M) (int64, error) {
-    collection := database.Client.Database("data_trackit").Collection("tasks")
-    result, err := collection.UpdateOne(
-        context.Background(),
-        bson.M{"_id": taskID},
-        bson.M{"$set": updateData},
-    )
-    return result.ModifiedCount, err
+// Apply an update document to the task matched by filter, returning the
+// matched count. Every update bumps the task's version.
+func updateTask(filter bson.M, update bson.M) (int64, error) {
+    bumpVersion(update)
+    collection := database.GetCollection(taskCollection)
+    result, err := collection.UpdateOne(context.Background(), filter, update)
+    if err != nil {
+        return 0, err
+    }
+    return result.MatchedCount, nil
 }
 
-// Delete a task from the database
-func deleteTask(taskID, userID primitive.ObjectID) (int64, error) {
-    collection := database.Client.Database("data_trackit").Collection("tasks")
-    result, err := collection.DeleteOne(context.Background(), bson.M{
-        "_id":        taskID,
-        "created_by": userID,
-    })
-    return result.DeletedCount, err
+// Translate validated input from JSON field names to BSON $set and $unset documents
+func taskInputToBSON(input *models.TaskInput) (bson.M, bson.M) {
+    jsonToBSON := getJSONToBSONMap(models.Task{})
+    set := make(bson.M)
+    for jsonKey, value := range input.Set {
+        if bsonKey, ok := jsonToBSON[jsonKey]; ok {
+            set[bsonKey] = value
+        }
+    }
+    unset := make(bson.M)
+    for _, jsonKey := range input.Unset {
+        if bsonKey, ok := jsonToBSON[jsonKey]; ok {
+            unset[bsonKey] = ""
+        }
+    }
+    return set, unset
+}
+
+// Copy BSON-keyed fields onto a task by round-tripping them through the codec
+func applyBSONFields(task *models.Task, fields bson.M) error {
+    data, err := bson.Marshal(fields)
+    if err != nil {
+        return err
+    }
+    return bson.Unmarshal(data, task)
+}
+
+// bumpVersion adds the version increment every task update carries
+func bumpVersion(update bson.M) {
+    update["$inc"] = bson.M{"version": 1}
+}
+
+// Move the task matched by filter to the trash, returning the matched count
+func trashTask(filter bson.M, by primitive.ObjectID, at time.Time) (int64, error) {
+    filter["deleted_at"] = nil
+    return updateTask(filter, trashUpdate(by, at))
+}
+
+// trashUpdate is the update that moves a task to the trash
+func trashUpdate(by primitive.ObjectID, at time.Time) bson.M {
+    return bson.M{"$set": bson.M{"deleted_at": at, "deleted_by": by}}
 }
 
 // Generate AI-based suggestions for a task
@@ -202,6 +421,11 @@ func generateAISuggestions(task models.Task) {
     }
 }
 
+// Respond with every invalid input field
+func respondWithValidationErrors(c *gin.Context, errs models.ValidationErrors) {
+    c.JSON(400, gin.H{"error": "Validation failed", "fields": errs})
+}
+
 // Respond with an error in a structured format
 func respondWithError(c *gin.Context, status int, message string, err error) {
     log.Printf("%s: %v", message, err)
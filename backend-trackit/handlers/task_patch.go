@@ -0,0 +1,274 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "reflect"
+    "sort"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+// Content types accepted by PATCH /api/tasks/:id
+const (
+    mergePatchContentType = "application/merge-patch+json"
+    jsonPatchContentType  = "application/json-patch+json"
+)
+
+// PatchTask applies a JSON Merge Patch (RFC 7396) or a JSON Patch
+// (RFC 6902) to a task, chosen by the Content-Type header. The patch is
+// applied to the task's JSON form, and the fields it changes are validated
+// and written like a PUT. With JSON Patch, elements added to the end of or
+// removed from an array are written with $push and $pull, and a patch that
+// contains a test operation only applies to the version it was tested on.
+func PatchTask(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    body, err := c.GetRawData()
+    if err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    var mergePatch map[string]interface{}
+    var ops []services.PatchOperation
+    contentType := c.ContentType()
+    switch contentType {
+    case mergePatchContentType:
+        if err := json.Unmarshal(body, &mergePatch); err != nil || mergePatch == nil {
+            c.JSON(400, gin.H{"error": "A merge patch must be a JSON object"})
+            return
+        }
+    case jsonPatchContentType:
+        if err := json.Unmarshal(body, &ops); err != nil {
+            c.JSON(400, gin.H{"error": "A JSON patch must be an array of operations"})
+            return
+        }
+    default:
+        c.JSON(415, gin.H{"error": "Content-Type must be " + mergePatchContentType + " or " + jsonPatchContentType})
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+
+    original, err := taskJSONDocument(task)
+    if err != nil {
+        respondWithError(c, 500, "Failed to patch task", err)
+        return
+    }
+    target, _ := taskJSONDocument(task)
+
+    var patched interface{}
+    if contentType == mergePatchContentType {
+        patched = services.ApplyMergePatch(target, mergePatch)
+    } else {
+        patched, err = services.ApplyJSONPatch(target, ops)
+        var patchErr *services.PatchError
+        if errors.As(err, &patchErr) {
+            status := 400
+            if patchErr.TestFailed {
+                status = 409
+            }
+            c.JSON(status, gin.H{"error": patchErr.Error(), "operation": patchErr.Index})
+            return
+        }
+    }
+
+    patchedObject, ok := patched.(map[string]interface{})
+    if !ok {
+        c.JSON(400, gin.H{"error": "The patched task must be a JSON object"})
+        return
+    }
+
+    payload, errs := patchedTaskFields(original, patchedObject)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+    if len(payload) == 0 {
+        respondWithUpdatedTask(c, task.ID, userID, "Task unchanged")
+        return
+    }
+
+    input, errs := models.ValidateTaskInput(payload, true)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    change := newTaskChange(task, role, userID, input)
+    change.Version = version
+    if contentType == jsonPatchContentType {
+        if version == nil && hasTestOperation(ops) {
+            change.Version = &task.Version
+        }
+        change.Push, change.Pull = arrayDeltas(task, change.Set)
+    }
+
+    if err := applyTaskChange(ctx, change); err != nil {
+        respondWithTaskUpdateError(c, err)
+        return
+    }
+
+    respondWithUpdatedTask(c, task.ID, userID, "Task updated successfully")
+}
+
+// ------------------ Helper Functions ------------------
+
+// taskJSONDocument returns the task as the client sees it, decoded into
+// generic JSON values
+func taskJSONDocument(task *models.Task) (map[string]interface{}, error) {
+    data, err := json.Marshal(task)
+    if err != nil {
+        return nil, err
+    }
+    var doc map[string]interface{}
+    err = json.Unmarshal(data, &doc)
+    return doc, err
+}
+
+// patchedTaskFields lists the top-level fields a patch changed, in the form
+// ValidateTaskInput takes, with nil for removed fields. Patches may not
+// touch server-managed fields.
+func patchedTaskFields(original, patched map[string]interface{}) (map[string]interface{}, models.ValidationErrors) {
+    payload := make(map[string]interface{})
+    for field, value := range patched {
+        if !reflect.DeepEqual(original[field], value) {
+            payload[field] = value
+        }
+    }
+    for field := range original {
+        if _, ok := patched[field]; !ok {
+            payload[field] = nil
+        }
+    }
+    // Custom fields are written key by key, so a removed key is sent as null
+    if after, ok := payload["custom_fields"].(map[string]interface{}); ok {
+        payload["custom_fields"] = customFieldsDelta(original["custom_fields"], after)
+    }
+
+    var errs models.ValidationErrors
+    for field := range payload {
+        if models.IsReadOnlyTaskField(field) {
+            errs = append(errs, models.FieldError{Field: field, Message: "is read-only"})
+        }
+    }
+    if errs != nil {
+        sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+        return nil, errs
+    }
+    return payload, nil
+}
+
+// customFieldsDelta lists the custom field values that differ between two
+// custom_fields objects, with nil for removed keys
+func customFieldsDelta(original interface{}, patched map[string]interface{}) map[string]interface{} {
+    before, _ := original.(map[string]interface{})
+    delta := make(map[string]interface{})
+    for key, value := range patched {
+        if !reflect.DeepEqual(before[key], value) {
+            delta[key] = value
+        }
+    }
+    for key := range before {
+        if _, ok := patched[key]; !ok {
+            delta[key] = nil
+        }
+    }
+    return delta
+}
+
+func hasTestOperation(ops []services.PatchOperation) bool {
+    for _, op := range ops {
+        if op.Op == "test" {
+            return true
+        }
+    }
+    return false
+}
+
+// arrayDeltas finds the array fields in set whose new value only appends to
+// or only removes from the stored one, returning the elements to add with
+// $addToSet and to $pull. Other changes stay a plain $set.
+func arrayDeltas(task *models.Task, set bson.M) (map[string][]interface{}, map[string][]interface{}) {
+    before, err := toBSONDocument(task)
+    if err != nil {
+        return nil, nil
+    }
+    after, err := toBSONDocument(set)
+    if err != nil {
+        return nil, nil
+    }
+
+    push := make(map[string][]interface{})
+    pull := make(map[string][]interface{})
+    for field, value := range after {
+        newItems, ok := value.(bson.A)
+        if !ok {
+            continue
+        }
+        oldItems, _ := before[field].(bson.A)
+        // $addToSet and $pull fail on a null field
+        if len(oldItems) == 0 {
+            continue
+        }
+
+        if len(newItems) > len(oldItems) && reflect.DeepEqual([]interface{}(newItems[:len(oldItems)]), []interface{}(oldItems)) {
+            push[field] = newItems[len(oldItems):]
+            continue
+        }
+        if removed, ok := removedElements(oldItems, newItems); ok && len(newItems) > 0 {
+            pull[field] = removed
+        }
+    }
+    return push, pull
+}
+
+// removedElements returns what was taken out of old to get new, if new is
+// old with some elements removed and none of them still present ($pull
+// removes every occurrence)
+func removedElements(old, new bson.A) ([]interface{}, bool) {
+    if len(new) >= len(old) {
+        return nil, false
+    }
+
+    var removed []interface{}
+    j := 0
+    for _, item := range old {
+        if j < len(new) && reflect.DeepEqual(item, new[j]) {
+            j++
+            continue
+        }
+        removed = append(removed, item)
+    }
+    if j != len(new) {
+        return nil, false
+    }
+    for _, item := range removed {
+        for _, kept := range new {
+            if reflect.DeepEqual(item, kept) {
+                return nil, false
+            }
+        }
+    }
+    return removed, true
+}
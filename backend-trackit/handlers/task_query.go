@@ -0,0 +1,378 @@
+package handlers
+
+import (
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "net/url"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+const (
+    defaultTaskPageSize = 50
+    maxTaskPageSize     = 200
+)
+
+// taskSortKeys maps sort values to the stored field that orders them, for
+// fields whose values don't sort meaningfully as strings
+var taskSortKeys = map[string]string{
+    "priority": "priority_rank",
+    "status":   "status_rank",
+}
+
+// customFieldParamPrefix starts the query parameters and sort values that
+// refer to a custom field
+const customFieldParamPrefix = "cf."
+
+// taskQuery is the parsed form of the GET /api/tasks query string
+type taskQuery struct {
+    Filter    bson.M
+    SortField string
+    SortOrder int
+    Limit     int64
+    After     *taskCursor
+}
+
+// taskCursor marks the last task of a page. It is handed to clients as an
+// opaque base64 string and echoes the sort it was produced for, so a cursor
+// cannot be replayed against a different ordering.
+type taskCursor struct {
+    SortField string             `bson:"s"`
+    SortOrder int                `bson:"o"`
+    Value     bson.RawValue      `bson:"v"`
+    ID        primitive.ObjectID `bson:"id"`
+}
+
+// visibleTasksFilter matches the tasks a user created or is assigned to,
+// leaving out the trash
+func visibleTasksFilter(userID primitive.ObjectID) bson.M {
+    return bson.M{
+        "$or": []bson.M{
+            {"created_by": userID},
+            {"assigned_to": userID},
+        },
+        "deleted_at": nil,
+    }
+}
+
+// parseTaskQuery builds a taskQuery from GET /api/tasks query parameters.
+//
+// Supported parameters:
+//   status, priority          comma separated values, matched with $in
+//   tags, tags_mode           comma separated tags; tags_mode is "any" (default) or "all"
+//   assignee                  a user ID, "me", or "none"
+//   due_before, due_after     RFC3339 timestamps
+//   created_before/after      RFC3339 timestamps
+//   updated_before/after      RFC3339 timestamps
+//   cf.<key>                  comma separated values of a custom field, matched with $in
+//   cf.<key>.min/max          inclusive bounds on a number or date custom field
+//   archived                  "exclude" (default), "include" or "only"
+//   sort                      priority, status, one of database.TaskSortFields or cf.<key>,
+//                             prefixed with "-" for descending
+//   limit                     page size, 1..200
+//   cursor                    the next_cursor value from a previous page
+//
+// customFields holds the custom field definitions, keyed by field key.
+func parseTaskQuery(params url.Values, userID primitive.ObjectID, customFields map[string]models.CustomField) (*taskQuery, error) {
+    conditions := []bson.M{visibleTasksFilter(userID)}
+
+    if statuses := splitQueryList(params.Get("status")); len(statuses) > 0 {
+        conditions = append(conditions, bson.M{"status": bson.M{"$in": statuses}})
+    }
+    if priorities := splitQueryList(params.Get("priority")); len(priorities) > 0 {
+        conditions = append(conditions, bson.M{"priority": bson.M{"$in": priorities}})
+    }
+
+    if tags := splitQueryList(params.Get("tags")); len(tags) > 0 {
+        switch mode := params.Get("tags_mode"); mode {
+        case "", "any":
+            conditions = append(conditions, bson.M{"tags": bson.M{"$in": tags}})
+        case "all":
+            conditions = append(conditions, bson.M{"tags": bson.M{"$all": tags}})
+        default:
+            return nil, fmt.Errorf("invalid tags_mode %q: must be \"any\" or \"all\"", mode)
+        }
+    }
+
+    if assignee := params.Get("assignee"); assignee != "" {
+        switch assignee {
+        case "me":
+            conditions = append(conditions, bson.M{"assigned_to": userID})
+        case "none":
+            // Matches a missing, null or empty assignee list
+            conditions = append(conditions, bson.M{"assigned_to": bson.M{"$in": bson.A{nil, bson.A{}}}})
+        default:
+            assigneeID, err := primitive.ObjectIDFromHex(assignee)
+            if err != nil {
+                return nil, fmt.Errorf("invalid assignee %q", assignee)
+            }
+            conditions = append(conditions, bson.M{"assigned_to": assigneeID})
+        }
+    }
+
+    for _, r := range []struct{ param, field string }{
+        {"due", "due_date"},
+        {"created", "created_at"},
+        {"updated", "updated_at"},
+    } {
+        rangeFilter, err := parseTimeRange(params, r.param)
+        if err != nil {
+            return nil, err
+        }
+        if rangeFilter != nil {
+            conditions = append(conditions, bson.M{r.field: rangeFilter})
+        }
+    }
+
+    switch archived := params.Get("archived"); archived {
+    case "", "exclude":
+        conditions = append(conditions, unarchivedTasksFilter())
+    case "only":
+        conditions = append(conditions, archivedTasksFilter())
+    case "include":
+    default:
+        return nil, fmt.Errorf("invalid archived %q: must be \"exclude\", \"include\" or \"only\"", archived)
+    }
+
+    customConditions, err := parseCustomFieldFilters(params, customFields)
+    if err != nil {
+        return nil, err
+    }
+    conditions = append(conditions, customConditions...)
+
+    query := &taskQuery{SortField: "created_at", SortOrder: -1, Limit: defaultTaskPageSize}
+
+    if sortParam := params.Get("sort"); sortParam != "" {
+        query.SortOrder = 1
+        if strings.HasPrefix(sortParam, "-") {
+            query.SortOrder = -1
+            sortParam = sortParam[1:]
+        }
+        if key, ok := strings.CutPrefix(sortParam, customFieldParamPrefix); ok {
+            field, defined := customFields[key]
+            if !defined || !field.Sortable() {
+                return nil, fmt.Errorf("invalid sort field %q", sortParam)
+            }
+            sortParam = "custom_fields." + key
+        } else if field, ok := taskSortKeys[sortParam]; ok {
+            sortParam = field
+        } else if !isTaskSortField(sortParam) {
+            return nil, fmt.Errorf("invalid sort field %q", sortParam)
+        }
+        query.SortField = sortParam
+    }
+
+    if limitParam := params.Get("limit"); limitParam != "" {
+        limit, err := strconv.ParseInt(limitParam, 10, 64)
+        if err != nil || limit < 1 || limit > maxTaskPageSize {
+            return nil, fmt.Errorf("invalid limit %q: must be between 1 and %d", limitParam, maxTaskPageSize)
+        }
+        query.Limit = limit
+    }
+
+    if cursorParam := params.Get("cursor"); cursorParam != "" {
+        cursor, err := decodeTaskCursor(cursorParam)
+        if err != nil {
+            return nil, err
+        }
+        if cursor.SortField != query.SortField || cursor.SortOrder != query.SortOrder {
+            return nil, errors.New("cursor does not match the requested sort")
+        }
+        query.After = cursor
+        conditions = append(conditions, cursor.filter())
+    }
+
+    query.Filter = bson.M{"$and": conditions}
+    return query, nil
+}
+
+// sort returns the Mongo sort document, using _id as a stable tie-breaker
+func (q *taskQuery) sort() bson.D {
+    return bson.D{
+        {Key: q.SortField, Value: q.SortOrder},
+        {Key: "_id", Value: q.SortOrder},
+    }
+}
+
+// filter matches the tasks that come strictly after the cursor position.
+// MongoDB orders missing/null values before everything else, which only
+// matters for optional fields such as due_date.
+func (cur *taskCursor) filter() bson.M {
+    op := "$gt"
+    if cur.SortOrder < 0 {
+        op = "$lt"
+    }
+    field := cur.SortField
+
+    if cur.Value.Type == bson.TypeNull || cur.Value.Type == bson.TypeUndefined {
+        tie := bson.M{field: nil, "_id": bson.M{op: cur.ID}}
+        if cur.SortOrder < 0 {
+            // Nulls come last in descending order, nothing follows them
+            return tie
+        }
+        return bson.M{"$or": []bson.M{tie, {field: bson.M{"$ne": nil}}}}
+    }
+
+    branches := []bson.M{
+        {field: bson.M{op: cur.Value}},
+        {field: cur.Value, "_id": bson.M{op: cur.ID}},
+    }
+    if cur.SortOrder < 0 {
+        branches = append(branches, bson.M{field: nil})
+    }
+    return bson.M{"$or": branches}
+}
+
+// encodeTaskCursor builds the cursor pointing just past the given task
+func encodeTaskCursor(q *taskQuery, last bson.Raw) (string, error) {
+    id, ok := last.Lookup("_id").ObjectIDOK()
+    if !ok {
+        return "", errors.New("task is missing an _id")
+    }
+
+    value, err := last.LookupErr(strings.Split(q.SortField, ".")...)
+    if err != nil {
+        value = bson.RawValue{Type: bson.TypeNull}
+    }
+
+    data, err := bson.Marshal(taskCursor{
+        SortField: q.SortField,
+        SortOrder: q.SortOrder,
+        Value:     value,
+        ID:        id,
+    })
+    if err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeTaskCursor parses a cursor produced by encodeTaskCursor
+func decodeTaskCursor(encoded string) (*taskCursor, error) {
+    data, err := base64.RawURLEncoding.DecodeString(encoded)
+    if err != nil {
+        return nil, errors.New("invalid cursor")
+    }
+
+    var cursor taskCursor
+    if err := bson.Unmarshal(data, &cursor); err != nil {
+        return nil, errors.New("invalid cursor")
+    }
+    if !isTaskSortField(cursor.SortField) || (cursor.SortOrder != 1 && cursor.SortOrder != -1) {
+        return nil, errors.New("invalid cursor")
+    }
+    return &cursor, nil
+}
+
+// parseTimeRange reads <prefix>_after and <prefix>_before as an inclusive range
+func parseTimeRange(params url.Values, prefix string) (bson.M, error) {
+    rangeFilter := bson.M{}
+    for _, bound := range []struct{ suffix, op string }{
+        {"_after", "$gte"},
+        {"_before", "$lte"},
+    } {
+        param := prefix + bound.suffix
+        raw := params.Get(param)
+        if raw == "" {
+            continue
+        }
+        t, err := time.Parse(time.RFC3339, raw)
+        if err != nil {
+            return nil, fmt.Errorf("invalid %s: expected an RFC3339 timestamp", param)
+        }
+        rangeFilter[bound.op] = t
+    }
+    if len(rangeFilter) == 0 {
+        return nil, nil
+    }
+    return rangeFilter, nil
+}
+
+// parseCustomFieldFilters reads the cf.<key> filters of the query string.
+// Values are converted to the field's stored type so they compare equal.
+func parseCustomFieldFilters(params url.Values, customFields map[string]models.CustomField) ([]bson.M, error) {
+    names := make([]string, 0, len(params))
+    for name := range params {
+        if strings.HasPrefix(name, customFieldParamPrefix) {
+            names = append(names, name)
+        }
+    }
+    sort.Strings(names)
+
+    var conditions []bson.M
+    for _, name := range names {
+        key, bound, _ := strings.Cut(strings.TrimPrefix(name, customFieldParamPrefix), ".")
+        field, ok := customFields[key]
+        if !ok {
+            return nil, fmt.Errorf("unknown custom field %q", key)
+        }
+        path := "custom_fields." + key
+
+        switch bound {
+        case "":
+            raw := splitQueryList(params.Get(name))
+            if len(raw) == 0 {
+                continue
+            }
+            values := make(bson.A, 0, len(raw))
+            for _, r := range raw {
+                value, err := field.ParseQueryValue(r)
+                if err != nil {
+                    return nil, fmt.Errorf("invalid %s: %v", name, err)
+                }
+                values = append(values, value)
+            }
+            conditions = append(conditions, bson.M{path: bson.M{"$in": values}})
+        case "min", "max":
+            if !field.Ranged() {
+                return nil, fmt.Errorf("invalid %s: only number and date fields have bounds", name)
+            }
+            value, err := field.ParseQueryValue(params.Get(name))
+            if err != nil {
+                return nil, fmt.Errorf("invalid %s: %v", name, err)
+            }
+            op := "$gte"
+            if bound == "max" {
+                op = "$lte"
+            }
+            conditions = append(conditions, bson.M{path: bson.M{op: value}})
+        default:
+            return nil, fmt.Errorf("invalid custom field filter %q", name)
+        }
+    }
+    return conditions, nil
+}
+
+// splitQueryList splits a comma separated query value, dropping empty items
+func splitQueryList(raw string) []string {
+    var values []string
+    for _, v := range strings.Split(raw, ",") {
+        if v = strings.TrimSpace(v); v != "" {
+            values = append(values, v)
+        }
+    }
+    return values
+}
+
+// isTaskSortField reports whether tasks can be sorted by the bson field.
+// Custom fields are accepted by shape; parseTaskQuery checks they exist.
+func isTaskSortField(field string) bool {
+    if key, ok := strings.CutPrefix(field, "custom_fields."); ok {
+        return models.IsValidCustomFieldKey(key)
+    }
+    for _, f := range database.TaskSortFields {
+        if f == field {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+    "context"
+    "fmt"
+    "html"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+const (
+    defaultSearchLimit = 20
+    maxSearchLimit     = 100
+    snippetRadius      = 80
+)
+
+// searchHit is a task matched by the text index along with its relevance score
+type searchHit struct {
+    models.Task `bson:",inline"`
+    Score       float64 `bson:"score"`
+}
+
+// SearchResult is one entry of the search response
+type SearchResult struct {
+    Task       models.Task       `json:"task"`
+    Score      float64           `json:"score"`
+    Highlights map[string]string `json:"highlights"`
+}
+
+// SearchTasks runs a full-text search over the tasks visible to the user.
+// The q parameter uses MongoDB text search syntax: "quoted phrases" must
+// appear verbatim and -word excludes tasks containing that word.
+func SearchTasks(c *gin.Context) {
+    userID := currentUserID(c)
+
+    q := strings.TrimSpace(c.Query("q"))
+    if q == "" {
+        c.JSON(400, gin.H{"error": "Query parameter q is required"})
+        return
+    }
+
+    limit, offset, err := parseSearchPaging(c)
+    if err != nil {
+        c.JSON(400, gin.H{"error": err.Error()})
+        return
+    }
+
+    hits, err := searchUserTasks(userID, q, unarchivedTasksFilter(), limit, offset)
+    if err != nil {
+        respondWithError(c, 500, "Failed to search tasks", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"results": searchResults(q, hits), "limit": limit, "offset": offset})
+}
+
+// ------------------ Helper Functions ------------------
+
+// Search the text index, restricted to the user's visible tasks that also
+// match scope
+func searchUserTasks(userID primitive.ObjectID, q string, scope bson.M, limit, offset int64) ([]searchHit, error) {
+    collection := database.GetCollection(taskCollection)
+    filter := bson.M{
+        "$and": []bson.M{
+            {"$text": bson.M{"$search": q}},
+            visibleTasksFilter(userID),
+            scope,
+        },
+    }
+    score := bson.M{"$meta": "textScore"}
+    opts := options.Find().
+        SetProjection(bson.M{"score": score}).
+        SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+        SetSkip(offset).
+        SetLimit(limit)
+
+    cursor, err := collection.Find(context.Background(), filter, opts)
+    if err != nil {
+        return nil, err
+    }
+
+    var hits []searchHit
+    if err := cursor.All(context.Background(), &hits); err != nil {
+        return nil, err
+    }
+    return hits, nil
+}
+
+// searchResults highlights the query's terms in each hit
+func searchResults(q string, hits []searchHit) []SearchResult {
+    highlighter := newHighlighter(q)
+    results := make([]SearchResult, 0, len(hits))
+    for _, hit := range hits {
+        results = append(results, SearchResult{
+            Task:       hit.Task,
+            Score:      hit.Score,
+            Highlights: highlighter.highlightTask(hit.Task),
+        })
+    }
+    return results
+}
+
+// parseSearchPaging reads the limit and offset query parameters
+func parseSearchPaging(c *gin.Context) (int64, int64, error) {
+    limit := int64(defaultSearchLimit)
+    if raw := c.Query("limit"); raw != "" {
+        parsed, err := strconv.ParseInt(raw, 10, 64)
+        if err != nil || parsed < 1 || parsed > maxSearchLimit {
+            return 0, 0, fmt.Errorf("invalid limit %q: must be between 1 and %d", raw, maxSearchLimit)
+        }
+        limit = parsed
+    }
+
+    var offset int64
+    if raw := c.Query("offset"); raw != "" {
+        parsed, err := strconv.ParseInt(raw, 10, 64)
+        if err != nil || parsed < 0 {
+            return 0, 0, fmt.Errorf("invalid offset %q", raw)
+        }
+        offset = parsed
+    }
+    return limit, offset, nil
+}
+
+// highlighter wraps the positive terms of a search query in <mark> tags.
+// MongoDB stems words before matching, so a term also highlights any word
+// it is a prefix of ("deploy" marks "deployment").
+type highlighter struct {
+    pattern *regexp.Regexp
+}
+
+var searchTokenPattern = regexp.MustCompile(`-?"[^"]*"|\S+`)
+
+func newHighlighter(q string) *highlighter {
+    var alternatives []string
+    for _, token := range searchTokenPattern.FindAllString(q, -1) {
+        if strings.HasPrefix(token, "-") {
+            continue
+        }
+        if strings.HasPrefix(token, `"`) {
+            if phrase := strings.TrimSpace(strings.Trim(token, `"`)); phrase != "" {
+                alternatives = append(alternatives, regexp.QuoteMeta(phrase))
+            }
+            continue
+        }
+        alternatives = append(alternatives, regexp.QuoteMeta(token)+`\w*`)
+    }
+    if len(alternatives) == 0 {
+        return &highlighter{}
+    }
+
+    // Longer alternatives first so phrases win over their individual words
+    sort.SliceStable(alternatives, func(i, j int) bool {
+        return len(alternatives[i]) > len(alternatives[j])
+    })
+    return &highlighter{
+        pattern: regexp.MustCompile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)`),
+    }
+}
+
+// highlightTask returns marked-up fragments for every field that matched
+func (h *highlighter) highlightTask(task models.Task) map[string]string {
+    highlights := make(map[string]string)
+    if h.pattern == nil {
+        return highlights
+    }
+
+    if h.pattern.MatchString(task.Title) {
+        highlights["title"] = h.mark(task.Title)
+    }
+    if snippet, ok := h.snippet(task.Description); ok {
+        highlights["description"] = snippet
+    }
+
+    var tags []string
+    for _, tag := range task.Tags {
+        if h.pattern.MatchString(tag) {
+            tags = append(tags, h.mark(tag))
+        }
+    }
+    if len(tags) > 0 {
+        highlights["tags"] = strings.Join(tags, ", ")
+    }
+    return highlights
+}
+
+// snippet cuts a window around the first match so long descriptions stay short
+func (h *highlighter) snippet(text string) (string, bool) {
+    loc := h.pattern.FindStringIndex(text)
+    if loc == nil {
+        return "", false
+    }
+
+    start := loc[0] - snippetRadius
+    end := loc[1] + snippetRadius
+    prefix, suffix := "…", "…"
+    if start <= 0 {
+        start, prefix = 0, ""
+    }
+    if end >= len(text) {
+        end, suffix = len(text), ""
+    }
+
+    // Avoid splitting a multi-byte rune at either edge
+    for start > 0 && !isRuneStart(text[start]) {
+        start--
+    }
+    for end < len(text) && !isRuneStart(text[end]) {
+        end++
+    }
+
+    return prefix + h.mark(text[start:end]) + suffix, true
+}
+
+// mark HTML-escapes the text so task content cannot inject markup, then
+// wraps each match in <mark> tags
+func (h *highlighter) mark(text string) string {
+    var b strings.Builder
+    last := 0
+    for _, loc := range h.pattern.FindAllStringIndex(text, -1) {
+        b.WriteString(html.EscapeString(text[last:loc[0]]))
+        b.WriteString("<mark>")
+        b.WriteString(html.EscapeString(text[loc[0]:loc[1]]))
+        b.WriteString("</mark>")
+        last = loc[1]
+    }
+    b.WriteString(html.EscapeString(text[last:]))
+    return b.String()
+}
+
+func isRuneStart(b byte) bool {
+    return b&0xC0 != 0x80
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+// taskChange is a validated update to a single task. UpdateTask and every
+// other endpoint that edits task fields goes through applyTaskChange so
+// authorization, workflow and hierarchy rules are enforced the same way.
+type taskChange struct {
+    // Task is the state before the change, Role the caller's role on it
+    Task   *models.Task
+    Role   services.TaskRole
+    UserID primitive.ObjectID
+    // Set and Unset are keyed by bson field name
+    Set   bson.M
+    Unset bson.M
+    // Push and Pull hold elements to add to or remove from array fields
+    // that are also in Set. They are written instead of the $set of that
+    // field, so concurrent edits to other elements survive. Pushed elements
+    // are added with $addToSet, since every such field holds a set.
+    Push map[string][]interface{}
+    Pull map[string][]interface{}
+    // Comment explains a status change, for transitions that require one
+    Comment string
+    // Version, when set, is the version the client last saw; the change is
+    // refused if the task has moved on since
+    Version *int64
+    // ColumnEnds, when set, holds the last rank handed out at the bottom of
+    // each status column by earlier changes in the same batch, whose writes
+    // haven't landed yet. Tasks moved to a column are placed below it and
+    // their rank is recorded in turn.
+    ColumnEnds map[string]string
+}
+
+// taskArrayLimits caps the array fields a change may add elements to
+var taskArrayLimits = map[string]int{
+    "tags":        models.MaxTags,
+    "assigned_to": models.MaxAssignees,
+}
+
+// taskUpdateError is a refused change along with the response to send
+type taskUpdateError struct {
+    Status int
+    Body   gin.H
+}
+
+func (e *taskUpdateError) Error() string {
+    return fmt.Sprintf("task update refused (%d): %v", e.Status, e.Body["error"])
+}
+
+// newTaskChange builds a change from validated input
+func newTaskChange(task *models.Task, role services.TaskRole, userID primitive.ObjectID, input *models.TaskInput) *taskChange {
+    set, unset := taskInputToBSON(input)
+    return &taskChange{Task: task, Role: role, UserID: userID, Set: set, Unset: unset}
+}
+
+// touches reports whether the change sets or unsets the field
+func (ch *taskChange) touches(field string) bool {
+    _, set := ch.Set[field]
+    _, unset := ch.Unset[field]
+    return set || unset
+}
+
+// fields lists every bson field the change sets or unsets
+func (ch *taskChange) fields() []string {
+    fields := make([]string, 0, len(ch.Set)+len(ch.Unset))
+    for field := range ch.Set {
+        fields = append(fields, field)
+    }
+    for field := range ch.Unset {
+        fields = append(fields, field)
+    }
+    return fields
+}
+
+// preparedTaskChange is a change that passed every task rule, with the
+// write that applies it and what is needed for the follow-up work
+type preparedTaskChange struct {
+    ch            *taskChange
+    filter        bson.M
+    update        bson.M
+    changes       []models.FieldChange
+    newAssignees  []primitive.ObjectID
+    newParent     *primitive.ObjectID
+    parentChanged bool
+}
+
+// applyTaskChange checks the change against every task rule, writes it and
+// runs the follow-up work (notifications, roll-ups)
+func applyTaskChange(ctx context.Context, ch *taskChange) error {
+    prepared, err := prepareTaskChange(ctx, ch)
+    if err != nil {
+        return err
+    }
+
+    matchedCount, err := updateTask(prepared.filter, prepared.update)
+    if err != nil {
+        return err
+    }
+    if matchedCount == 0 {
+        return staleTaskError(ctx, ch.Task, ch.UserID)
+    }
+
+    prepared.finish(ctx)
+    return nil
+}
+
+// prepareTaskChange checks the change against every task rule and builds
+// the filter and update that write it
+func prepareTaskChange(ctx context.Context, ch *taskChange) (*preparedTaskChange, error) {
+    task := ch.Task
+
+    if denied := ch.Role.DeniedFields(ch.fields()); len(denied) > 0 {
+        return nil, &taskUpdateError{403, gin.H{"error": "Not allowed to update these fields", "fields": denied}}
+    }
+
+    if err := expandCustomFieldChange(ctx, ch); err != nil {
+        return nil, err
+    }
+
+    // Keep the sort keys in step with the fields they order
+    if priority, ok := ch.Set["priority"].(string); ok {
+        ch.Set["priority_rank"] = models.PriorityRank(priority)
+    }
+    if status, ok := ch.Set["status"].(string); ok {
+        ch.Set["status_rank"] = models.StatusRank(status)
+    }
+
+    newAssignees, _ := ch.Set["assigned_to"].([]primitive.ObjectID)
+    if ch.touches("assigned_to") {
+        if errs, err := validateAssigneesExist(ctx, newAssignees); err != nil {
+            return nil, err
+        } else if errs != nil {
+            return nil, validationFailed(errs)
+        }
+    }
+
+    now := time.Now()
+    ch.Set["updated_at"] = now
+    filter := taskAccessFilter(task.ID, ch.UserID, ch.Role)
+    if ch.Version != nil {
+        filter["version"] = *ch.Version
+    }
+    // An addition only applies while it keeps the field within its limit,
+    // so concurrent additions can't grow it past the limit together
+    for field, values := range ch.Push {
+        if limit, ok := taskArrayLimits[field]; ok && len(values) <= limit {
+            filter[fmt.Sprintf("%s.%d", field, limit-len(values))] = bson.M{"$exists": false}
+        }
+    }
+
+    // Status changes must follow the workflow, judged against the assignee
+    // the task will have once this update is applied
+    if newStatus, ok := ch.Set["status"].(string); ok && newStatus != task.Status {
+        workflow, err := loadWorkflow(ctx)
+        if err != nil {
+            return nil, err
+        }
+
+        hasAssignee := len(task.AssignedTo) > 0
+        if ch.touches("assigned_to") {
+            hasAssignee = len(newAssignees) > 0
+        }
+
+        if err := services.CheckTransition(workflow, task.Status, newStatus, hasAssignee, ch.Comment); err != nil {
+            status, body := transitionErrorResponse(err)
+            return nil, &taskUpdateError{status, body}
+        }
+
+        if newStatus == models.StatusDone {
+            if err := checkBlockersResolved(ctx, task); err != nil {
+                return nil, err
+            }
+        }
+
+        // A task that changes column goes to the bottom of the new one,
+        // unless the change places it itself
+        if _, ok := ch.Set["rank"]; !ok {
+            var rank string
+            var err error
+            if last, ok := ch.ColumnEnds[newStatus]; ok {
+                rank, err = services.RankBetween(last, "")
+            } else {
+                rank, err = rankAtColumnEnd(ctx, newStatus, task.ID)
+            }
+            if err != nil {
+                return nil, err
+            }
+            ch.Set["rank"] = rank
+            if ch.ColumnEnds != nil {
+                ch.ColumnEnds[newStatus] = rank
+            }
+        }
+
+        ch.Set["last_transition"] = models.StatusTransition{
+            From:    task.Status,
+            To:      newStatus,
+            Comment: ch.Comment,
+            By:      ch.UserID,
+            At:      now,
+        }
+        if newStatus == models.StatusDone {
+            ch.Set["completed_at"] = now
+        } else if task.CompletedAt != nil {
+            ch.Unset["completed_at"] = ""
+        }
+        filter["status"] = task.Status
+    }
+
+    newParent, parentChanged := parentAfterChange(ch)
+    if parentChanged && newParent != nil {
+        if err := validateParent(ctx, task.ID, *newParent, ch.UserID); err != nil {
+            return nil, err
+        }
+    }
+
+    return &preparedTaskChange{
+        ch:            ch,
+        filter:        filter,
+        update:        ch.updateDocument(),
+        changes:       taskFieldChanges(task, ch.Set, ch.Unset),
+        newAssignees:  newAssignees,
+        newParent:     newParent,
+        parentChanged: parentChanged,
+    }, nil
+}
+
+// finish runs the follow-up work once the change has been written
+func (p *preparedTaskChange) finish(ctx context.Context) {
+    ch, task := p.ch, p.ch.Task
+
+    if len(p.changes) > 0 {
+        entry := newActivity(task.ID, ch.UserID, models.ActivityTaskUpdated)
+        entry.Changes = p.changes
+        recordActivity(ctx, entry)
+    }
+
+    if ch.touches("assigned_to") {
+        updated := *task
+        updated.AssignedTo = p.newAssignees
+        notifyAssignmentChange(updated, task.AssignedTo, ch.UserID)
+    }
+
+    // Keep the completion roll-up of every affected parent current
+    if p.parentChanged {
+        refreshSubtaskRollups(ctx, task.ParentID)
+        refreshSubtaskRollups(ctx, p.newParent)
+    } else if ch.touches("status") || ch.touches("progress") {
+        refreshSubtaskRollups(ctx, task.ParentID)
+    }
+}
+
+// updateDocument builds the MongoDB update that applies the change
+func (ch *taskChange) updateDocument() bson.M {
+    set := make(bson.M, len(ch.Set))
+    for field, value := range ch.Set {
+        set[field] = value
+    }
+
+    update := bson.M{}
+    if len(ch.Push) > 0 {
+        push := bson.M{}
+        for field, values := range ch.Push {
+            push[field] = bson.M{"$each": values}
+            delete(set, field)
+        }
+        update["$addToSet"] = push
+    }
+    if len(ch.Pull) > 0 {
+        pull := bson.M{}
+        for field, values := range ch.Pull {
+            pull[field] = bson.M{"$in": values}
+            delete(set, field)
+        }
+        update["$pull"] = pull
+    }
+
+    update["$set"] = set
+    if len(ch.Unset) > 0 {
+        update["$unset"] = ch.Unset
+    }
+    return update
+}
+
+// parentAfterChange returns the parent the task will have after the change
+// and whether that differs from the current one
+func parentAfterChange(ch *taskChange) (*primitive.ObjectID, bool) {
+    if _, ok := ch.Unset["parent_id"]; ok {
+        return nil, ch.Task.ParentID != nil
+    }
+    parent, ok := ch.Set["parent_id"].(primitive.ObjectID)
+    if !ok {
+        return ch.Task.ParentID, false
+    }
+    return &parent, ch.Task.ParentID == nil || *ch.Task.ParentID != parent
+}
+
+// staleTaskError explains why a conditional write to a task matched nothing:
+// a 412 with the current task when someone else changed it, otherwise
+// errTaskNotFound
+func staleTaskError(ctx context.Context, task *models.Task, userID primitive.ObjectID) error {
+    current, _, err := loadTaskForUser(ctx, task.ID, userID)
+    if err != nil {
+        return err
+    }
+    if current.Version != task.Version {
+        return &taskUpdateError{412, versionConflictBody(current)}
+    }
+    return errTaskNotFound
+}
+
+// validationFailed wraps field errors as a refused update
+func validationFailed(errs models.ValidationErrors) *taskUpdateError {
+    return &taskUpdateError{400, gin.H{"error": "Validation failed", "fields": errs}}
+}
+
+// respondWithTaskUpdateError sends the response for an applyTaskChange error
+func respondWithTaskUpdateError(c *gin.Context, err error) {
+    var updateErr *taskUpdateError
+    if errors.As(err, &updateErr) {
+        if current, ok := updateErr.Body["task"].(*models.Task); ok && updateErr.Status == 412 {
+            setTaskETag(c, current)
+        }
+        c.JSON(updateErr.Status, updateErr.Body)
+        return
+    }
+    if errors.Is(err, errTaskNotFound) {
+        c.JSON(404, gin.H{"error": "Task not found"})
+        return
+    }
+    respondWithError(c, 500, "Failed to update task", err)
+}
@@ -0,0 +1,484 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+const templateCollection = "templates"
+
+// TemplateNameMaxLength limits the length of a template's name
+const TemplateNameMaxLength = 100
+
+// templateTaskFields are the task fields a template can hold
+var templateTaskFields = map[string]bool{
+    "title":       true,
+    "description": true,
+    "priority":    true,
+    "tags":        true,
+    "assigned_to": true,
+}
+
+var errTemplateNotFound = errors.New("template not found")
+
+// templateOptions controls how a template becomes a task
+type templateOptions struct {
+    // Variables add to or override the built-in template variables
+    Variables map[string]string
+    // Overrides are task fields, in JSON form, that replace the template's
+    Overrides map[string]interface{}
+    // Location and Now decide the {{date}}, {{time}} and {{weekday}} values
+    Location *time.Location
+    Now      time.Time
+    // Recurrence links the task to the recurring task that created it
+    Recurrence *models.TaskRecurrence
+}
+
+// CreateTemplate saves a new task template owned by the caller
+func CreateTemplate(c *gin.Context) {
+    userID := currentUserID(c)
+
+    var payload map[string]interface{}
+    if err := c.ShouldBindJSON(&payload); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    name, input, errs := validateTemplateInput(payload, false)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if err := checkTemplateAssignees(ctx, input); err != nil {
+        respondWithTaskCreateError(c, err)
+        return
+    }
+
+    template := models.TaskTemplate{
+        ID:        primitive.NewObjectID(),
+        Name:      *name,
+        Priority:  models.PriorityMedium,
+        Tags:      []string{},
+        CreatedBy: userID,
+        CreatedAt: time.Now(),
+        UpdatedAt: time.Now(),
+    }
+    set, _ := taskInputToBSON(input)
+    if err := applyTemplateFields(&template, set); err != nil {
+        respondWithError(c, 500, "Failed to create template", err)
+        return
+    }
+
+    if _, err := database.GetCollection(templateCollection).InsertOne(ctx, template); err != nil {
+        respondWithError(c, 500, "Failed to create template", err)
+        return
+    }
+
+    c.JSON(201, gin.H{"message": "Template created successfully", "template": template})
+}
+
+// GetTemplates lists the caller's templates by name
+func GetTemplates(c *gin.Context) {
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    cursor, err := database.GetCollection(templateCollection).Find(ctx,
+        bson.M{"created_by": userID},
+        options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch templates", err)
+        return
+    }
+
+    templates := []models.TaskTemplate{}
+    if err := cursor.All(ctx, &templates); err != nil {
+        respondWithError(c, 500, "Failed to fetch templates", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"templates": templates})
+}
+
+// GetTemplate returns one of the caller's templates
+func GetTemplate(c *gin.Context) {
+    templateID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    template, err := loadTemplateForUser(ctx, templateID, currentUserID(c))
+    if err != nil {
+        respondWithTemplateLookupError(c, err)
+        return
+    }
+
+    c.JSON(200, gin.H{"template": template})
+}
+
+// UpdateTemplate changes the fields given in the payload
+func UpdateTemplate(c *gin.Context) {
+    templateID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var payload map[string]interface{}
+    if err := c.ShouldBindJSON(&payload); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    name, input, errs := validateTemplateInput(payload, true)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if err := checkTemplateAssignees(ctx, input); err != nil {
+        respondWithTaskCreateError(c, err)
+        return
+    }
+
+    set, unset := taskInputToBSON(input)
+    set["updated_at"] = time.Now()
+    // Clearing tags keeps an empty list, like a new template
+    if _, ok := unset["tags"]; ok {
+        delete(unset, "tags")
+        set["tags"] = []string{}
+    }
+    if name != nil {
+        set["name"] = *name
+    }
+    update := bson.M{"$set": set}
+    if len(unset) > 0 {
+        update["$unset"] = unset
+    }
+
+    var template models.TaskTemplate
+    err := database.GetCollection(templateCollection).FindOneAndUpdate(ctx,
+        bson.M{"_id": templateID, "created_by": userID},
+        update,
+        options.FindOneAndUpdate().SetReturnDocument(options.After),
+    ).Decode(&template)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        c.JSON(404, gin.H{"error": "Template not found"})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to update template", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"message": "Template updated successfully", "template": template})
+}
+
+// DeleteTemplate removes one of the caller's templates. Tasks created from
+// it are not affected.
+func DeleteTemplate(c *gin.Context) {
+    templateID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := database.GetCollection(templateCollection).DeleteOne(ctx,
+        bson.M{"_id": templateID, "created_by": currentUserID(c)},
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to delete template", err)
+        return
+    }
+    if result.DeletedCount == 0 {
+        c.JSON(404, gin.H{"error": "Template not found"})
+        return
+    }
+
+    c.JSON(200, gin.H{"message": "Template deleted successfully"})
+}
+
+// InstantiateTemplate creates a task from a template. The body may give
+// variables for the title and description, overrides for any task field
+// and the timezone used for {{date}}, {{time}} and {{weekday}}.
+func InstantiateTemplate(c *gin.Context) {
+    templateID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        Variables map[string]string      `json:"variables"`
+        Overrides map[string]interface{} `json:"overrides"`
+        Timezone  string                 `json:"timezone"`
+    }
+    // An empty body instantiates the template as it is
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&input); err != nil {
+            respondWithError(c, 400, "Invalid request payload", err)
+            return
+        }
+    }
+
+    location := time.UTC
+    if input.Timezone != "" {
+        loaded, err := time.LoadLocation(input.Timezone)
+        if err != nil {
+            respondWithValidationErrors(c, models.ValidationErrors{{Field: "timezone", Message: "unknown time zone"}})
+            return
+        }
+        location = loaded
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    template, err := loadTemplateForUser(ctx, templateID, userID)
+    if err != nil {
+        respondWithTemplateLookupError(c, err)
+        return
+    }
+
+    task, err := instantiateTemplate(ctx, template, userID, templateOptions{
+        Variables: input.Variables,
+        Overrides: input.Overrides,
+        Location:  location,
+        Now:       time.Now(),
+    })
+    if err != nil {
+        respondWithTaskCreateError(c, err)
+        return
+    }
+
+    setTaskETag(c, task)
+    c.JSON(201, gin.H{"message": "Task created from template", "task": task})
+}
+
+// ------------------ Helper Functions ------------------
+
+// Validate a template payload. name is nil when a partial payload leaves it
+// out; the remaining fields follow the task schema.
+func validateTemplateInput(payload map[string]interface{}, partial bool) (*string, *models.TaskInput, models.ValidationErrors) {
+    var errs models.ValidationErrors
+    var name *string
+
+    fields := make(map[string]interface{}, len(payload))
+    for field, value := range payload {
+        switch {
+        case field == "name":
+            s, ok := value.(string)
+            s = strings.TrimSpace(s)
+            if !ok || s == "" {
+                errs = append(errs, models.FieldError{Field: "name", Message: "must be a non-empty string"})
+            } else if utf8.RuneCountInString(s) > TemplateNameMaxLength {
+                errs = append(errs, models.FieldError{Field: "name", Message: fmt.Sprintf("must be at most %d characters", TemplateNameMaxLength)})
+            } else {
+                name = &s
+            }
+        case templateTaskFields[field] || models.IsReadOnlyTaskField(field):
+            fields[field] = value
+        default:
+            errs = append(errs, models.FieldError{Field: field, Message: "unknown field"})
+        }
+    }
+    if !partial && name == nil && payload["name"] == nil {
+        errs = append(errs, models.FieldError{Field: "name", Message: "is required"})
+    }
+
+    input, inputErrs := models.ValidateTaskInput(fields, partial)
+    errs = append(errs, inputErrs...)
+    if len(errs) > 0 {
+        return nil, nil, errs
+    }
+    return name, input, nil
+}
+
+// Check that the assignees a template names are registered users
+func checkTemplateAssignees(ctx context.Context, input *models.TaskInput) error {
+    assignees, ok := input.Set["assigned_to"].([]primitive.ObjectID)
+    if !ok {
+        return nil
+    }
+    errs, err := validateAssigneesExist(ctx, assignees)
+    if err != nil {
+        return err
+    }
+    if errs != nil {
+        return validationFailed(errs)
+    }
+    return nil
+}
+
+// Copy BSON-keyed fields onto a template through the codec
+func applyTemplateFields(template *models.TaskTemplate, fields bson.M) error {
+    data, err := bson.Marshal(fields)
+    if err != nil {
+        return err
+    }
+    return bson.Unmarshal(data, template)
+}
+
+// Fetch a template owned by the user
+func loadTemplateForUser(ctx context.Context, templateID, userID primitive.ObjectID) (*models.TaskTemplate, error) {
+    var template models.TaskTemplate
+    err := database.GetCollection(templateCollection).
+        FindOne(ctx, bson.M{"_id": templateID, "created_by": userID}).
+        Decode(&template)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, errTemplateNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &template, nil
+}
+
+func respondWithTemplateLookupError(c *gin.Context, err error) {
+    if errors.Is(err, errTemplateNotFound) {
+        c.JSON(404, gin.H{"error": "Template not found"})
+        return
+    }
+    respondWithError(c, 500, "Failed to fetch template", err)
+}
+
+// instantiateTemplate creates a task owned by userID from a template:
+// overrides are applied, the title and description rendered and the result
+// goes through the same checks as any new task
+func instantiateTemplate(ctx context.Context, template *models.TaskTemplate, userID primitive.ObjectID, opts templateOptions) (*models.Task, error) {
+    payload := map[string]interface{}{
+        "title":       template.Title,
+        "description": template.Description,
+    }
+    if template.Priority != "" {
+        payload["priority"] = template.Priority
+    }
+    if len(template.Tags) > 0 {
+        tags := make([]interface{}, len(template.Tags))
+        for i, tag := range template.Tags {
+            tags[i] = tag
+        }
+        payload["tags"] = tags
+    }
+    if len(template.AssignedTo) > 0 {
+        assignees := make([]interface{}, len(template.AssignedTo))
+        for i, id := range template.AssignedTo {
+            assignees[i] = id.Hex()
+        }
+        payload["assigned_to"] = assignees
+    }
+    for field, value := range opts.Overrides {
+        payload[field] = value
+    }
+
+    input, errs := models.ValidateTaskInput(payload, false)
+    if errs != nil {
+        return nil, validationFailed(errs)
+    }
+
+    assignees, _ := input.Set["assigned_to"].([]primitive.ObjectID)
+    vars, err := templateVariables(ctx, template, userID, assignees, opts)
+    if err != nil {
+        return nil, err
+    }
+
+    rendered := make(map[string]interface{})
+    var unknown []string
+    for _, field := range []string{"title", "description"} {
+        text, ok := input.Set[field].(string)
+        if !ok {
+            continue
+        }
+        result, missing := services.RenderTemplate(text, vars)
+        rendered[field] = result
+        unknown = append(unknown, missing...)
+    }
+    if len(unknown) > 0 {
+        return nil, &taskUpdateError{400, gin.H{"error": "Unknown template variables", "variables": unknown}}
+    }
+
+    // Rendering can change the length of the text, so check it again
+    renderedInput, errs := models.ValidateTaskInput(rendered, true)
+    if errs != nil {
+        return nil, validationFailed(errs)
+    }
+    for field, value := range renderedInput.Set {
+        input.Set[field] = value
+    }
+
+    return createTask(ctx, input, userID, opts.Recurrence)
+}
+
+// Build the variables available to a template: date, time, weekday,
+// assignee (assignee emails), creator (the creator's email) and template
+// (the template name), plus the caller's own variables
+func templateVariables(ctx context.Context, template *models.TaskTemplate, userID primitive.ObjectID, assignees []primitive.ObjectID, opts templateOptions) (map[string]string, error) {
+    emails, err := userEmails(ctx, append([]primitive.ObjectID{userID}, assignees...))
+    if err != nil {
+        return nil, err
+    }
+
+    var assigneeEmails []string
+    for _, id := range assignees {
+        if email, ok := emails[id]; ok {
+            assigneeEmails = append(assigneeEmails, email)
+        }
+    }
+
+    location := opts.Location
+    if location == nil {
+        location = time.UTC
+    }
+    now := opts.Now.In(location)
+
+    vars := map[string]string{
+        "date":     now.Format("2006-01-02"),
+        "time":     now.Format("15:04"),
+        "weekday":  now.Weekday().String(),
+        "assignee": strings.Join(assigneeEmails, ", "),
+        "creator":  emails[userID],
+        "template": template.Name,
+    }
+    for name, value := range opts.Variables {
+        vars[name] = value
+    }
+    return vars, nil
+}
+
+// Look up the email address of each user
+func userEmails(ctx context.Context, userIDs []primitive.ObjectID) (map[primitive.ObjectID]string, error) {
+    cursor, err := database.GetCollection(userCollection).Find(ctx,
+        bson.M{"_id": bson.M{"$in": userIDs}},
+        options.Find().SetProjection(bson.M{"email": 1}),
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    var users []models.User
+    if err := cursor.All(ctx, &users); err != nil {
+        return nil, err
+    }
+
+    emails := make(map[primitive.ObjectID]string, len(users))
+    for _, user := range users {
+        emails[user.ID] = user.Email
+    }
+    return emails, nil
+}
@@ -0,0 +1,639 @@
+package handlers
+
+import (
+    "context"
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+const timeEntryCollection = "time_entries"
+
+// maxTimeEntryExport bounds how many entries GET /api/time-entries returns
+const maxTimeEntryExport = 10000
+
+// clockSkewAllowance is how far in the future a manual entry may end
+const clockSkewAllowance = time.Minute
+
+var errTimeEntryNotFound = errors.New("time entry not found")
+
+// timeEntryPayload is the body of manual entry create and update requests.
+// An entry is given by its start and either its end or its duration.
+type timeEntryPayload struct {
+    StartedAt *time.Time `json:"started_at"`
+    EndedAt   *time.Time `json:"ended_at"`
+    Duration  *int64     `json:"duration"` // seconds
+    Note      *string    `json:"note"`
+}
+
+// StartTimer starts the caller's timer on a task. A user has one running
+// timer at a time; starting another while one runs is a conflict.
+func StartTimer(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        Note string `json:"note"`
+    }
+    // The body is optional
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&input); err != nil {
+            respondWithError(c, 400, "Invalid request payload", err)
+            return
+        }
+    }
+    if errs := validateTimeEntryNote(input.Note); errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if _, _, err := loadTaskForUser(ctx, taskID, userID); err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    now := time.Now()
+    entry := models.TimeEntry{
+        ID:        primitive.NewObjectID(),
+        TaskID:    taskID,
+        UserID:    userID,
+        Source:    models.TimeEntryTimer,
+        Running:   true,
+        StartedAt: now,
+        Note:      strings.TrimSpace(input.Note),
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    _, err := database.GetCollection(timeEntryCollection).InsertOne(ctx, entry)
+    if mongo.IsDuplicateKeyError(err) {
+        running, _ := runningTimer(ctx, userID)
+        c.JSON(409, gin.H{"error": "You already have a running timer", "time_entry": running})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to start timer", err)
+        return
+    }
+
+    c.JSON(201, gin.H{"message": "Timer started", "time_entry": entry})
+}
+
+// StopTimer stops the caller's running timer on a task and adds the time
+// to the task. It works on tasks the caller can no longer see, so a timer
+// is never stuck running.
+func StopTimer(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        Note *string `json:"note"`
+    }
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&input); err != nil {
+            respondWithError(c, 400, "Invalid request payload", err)
+            return
+        }
+    }
+    if input.Note != nil {
+        if errs := validateTimeEntryNote(*input.Note); errs != nil {
+            respondWithValidationErrors(c, errs)
+            return
+        }
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var running models.TimeEntry
+    err := database.GetCollection(timeEntryCollection).
+        FindOne(ctx, bson.M{"task_id": taskID, "user_id": userID, "running": true}).
+        Decode(&running)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        c.JSON(404, gin.H{"error": "No running timer on this task"})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to stop timer", err)
+        return
+    }
+
+    now := time.Now()
+    set := bson.M{
+        "running":    false,
+        "ended_at":   now,
+        "duration":   int64(now.Sub(running.StartedAt).Seconds()),
+        "updated_at": now,
+    }
+    if input.Note != nil {
+        set["note"] = strings.TrimSpace(*input.Note)
+    }
+
+    var entry models.TimeEntry
+    err = database.GetCollection(timeEntryCollection).FindOneAndUpdate(ctx,
+        bson.M{"_id": running.ID, "running": true},
+        bson.M{"$set": set},
+        options.FindOneAndUpdate().SetReturnDocument(options.After),
+    ).Decode(&entry)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        // Stopped by a concurrent request
+        c.JSON(404, gin.H{"error": "No running timer on this task"})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to stop timer", err)
+        return
+    }
+    addTimeSpent(ctx, taskID, entry.Duration)
+
+    c.JSON(200, gin.H{"message": "Timer stopped", "time_entry": entry})
+}
+
+// GetRunningTimer returns the caller's running timer, or null
+func GetRunningTimer(c *gin.Context) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    running, err := runningTimer(ctx, currentUserID(c))
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch timer", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"time_entry": running})
+}
+
+// CreateTimeEntry records time the caller spent on a task by hand
+func CreateTimeEntry(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var payload timeEntryPayload
+    if err := c.ShouldBindJSON(&payload); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    entry := models.TimeEntry{
+        ID:        primitive.NewObjectID(),
+        TaskID:    taskID,
+        UserID:    userID,
+        Source:    models.TimeEntryManual,
+        CreatedAt: time.Now(),
+        UpdatedAt: time.Now(),
+    }
+    if errs := payload.applyTo(&entry, false); errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if _, _, err := loadTaskForUser(ctx, taskID, userID); err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    if _, err := database.GetCollection(timeEntryCollection).InsertOne(ctx, entry); err != nil {
+        respondWithError(c, 500, "Failed to create time entry", err)
+        return
+    }
+    addTimeSpent(ctx, taskID, entry.Duration)
+
+    c.JSON(201, gin.H{"message": "Time entry created successfully", "time_entry": entry})
+}
+
+// GetTaskTimeEntries lists everyone's time entries on a task, newest first,
+// with the task's estimated and spent totals
+func GetTaskTimeEntries(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, _, err := loadTaskForUser(ctx, taskID, currentUserID(c))
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+
+    cursor, err := database.GetCollection(timeEntryCollection).Find(ctx,
+        bson.M{"task_id": taskID},
+        options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}, {Key: "_id", Value: -1}}),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch time entries", err)
+        return
+    }
+
+    entries := []models.TimeEntry{}
+    if err := cursor.All(ctx, &entries); err != nil {
+        respondWithError(c, 500, "Failed to fetch time entries", err)
+        return
+    }
+
+    c.JSON(200, gin.H{
+        "time_entries":  entries,
+        "time_estimate": task.TimeEstimate,
+        "time_spent":    task.TimeSpent,
+    })
+}
+
+// UpdateTimeEntry edits one of the caller's finished time entries
+func UpdateTimeEntry(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    entryID, _ := primitive.ObjectIDFromHex(c.Param("entryId"))
+    userID := currentUserID(c)
+
+    var payload timeEntryPayload
+    if err := c.ShouldBindJSON(&payload); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    entry, err := loadTimeEntryForUser(ctx, taskID, entryID, userID)
+    if err != nil {
+        respondWithTimeEntryLookupError(c, err)
+        return
+    }
+    if entry.Running {
+        c.JSON(409, gin.H{"error": "Stop the timer before editing the entry"})
+        return
+    }
+
+    previous := entry.Duration
+    if errs := payload.applyTo(entry, true); errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    // Matching the old duration keeps the task's total right when two
+    // edits race
+    result, err := database.GetCollection(timeEntryCollection).UpdateOne(ctx,
+        bson.M{"_id": entry.ID, "running": false, "duration": previous},
+        bson.M{"$set": bson.M{
+            "started_at": entry.StartedAt,
+            "ended_at":   entry.EndedAt,
+            "duration":   entry.Duration,
+            "note":       entry.Note,
+            "updated_at": time.Now(),
+        }},
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to update time entry", err)
+        return
+    }
+    if result.MatchedCount == 0 {
+        c.JSON(409, gin.H{"error": "Time entry was modified by someone else"})
+        return
+    }
+    addTimeSpent(ctx, taskID, entry.Duration-previous)
+
+    c.JSON(200, gin.H{"message": "Time entry updated successfully", "time_entry": entry})
+}
+
+// DeleteTimeEntry removes one of the caller's time entries, running or not
+func DeleteTimeEntry(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    entryID, _ := primitive.ObjectIDFromHex(c.Param("entryId"))
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var entry models.TimeEntry
+    err := database.GetCollection(timeEntryCollection).FindOneAndDelete(ctx,
+        bson.M{"_id": entryID, "task_id": taskID, "user_id": currentUserID(c)},
+    ).Decode(&entry)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        c.JSON(404, gin.H{"error": "Time entry not found"})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to delete time entry", err)
+        return
+    }
+    if !entry.Running {
+        addTimeSpent(ctx, taskID, -entry.Duration)
+    }
+
+    c.JSON(200, gin.H{"message": "Time entry deleted successfully"})
+}
+
+// GetTimeEntries lists the caller's finished time entries that started in
+// [from, to), oldest first, as JSON or, with format=csv, as a CSV file for
+// invoicing. from and to are RFC3339 timestamps or dates; a date in to
+// includes that whole day. Dates are read in the timezone parameter (UTC by
+// default), which is also used for the CSV's timestamps. task_id narrows
+// the list to one task.
+func GetTimeEntries(c *gin.Context) {
+    userID := currentUserID(c)
+
+    format := c.DefaultQuery("format", "json")
+    if format != "json" && format != "csv" {
+        c.JSON(400, gin.H{"error": "format must be json or csv"})
+        return
+    }
+
+    location := time.UTC
+    if tz := c.Query("timezone"); tz != "" {
+        loaded, err := time.LoadLocation(tz)
+        if err != nil {
+            c.JSON(400, gin.H{"error": fmt.Sprintf("unknown timezone %q", tz)})
+            return
+        }
+        location = loaded
+    }
+
+    startedAt := bson.M{}
+    for _, bound := range []struct {
+        param, op string
+        endOfDay  bool
+    }{{"from", "$gte", false}, {"to", "$lt", true}} {
+        raw := c.Query(bound.param)
+        if raw == "" {
+            continue
+        }
+        t, err := parseTimeBound(raw, location, bound.endOfDay)
+        if err != nil {
+            c.JSON(400, gin.H{"error": fmt.Sprintf("invalid %s %q: must be an RFC3339 timestamp or a YYYY-MM-DD date", bound.param, raw)})
+            return
+        }
+        startedAt[bound.op] = t
+    }
+
+    filter := bson.M{"user_id": userID, "running": false}
+    if len(startedAt) > 0 {
+        filter["started_at"] = startedAt
+    }
+    if raw := c.Query("task_id"); raw != "" {
+        taskID, err := primitive.ObjectIDFromHex(raw)
+        if err != nil {
+            c.JSON(400, gin.H{"error": "invalid task_id"})
+            return
+        }
+        filter["task_id"] = taskID
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    cursor, err := database.GetCollection(timeEntryCollection).Find(ctx, filter,
+        options.Find().
+            SetSort(bson.D{{Key: "started_at", Value: 1}, {Key: "_id", Value: 1}}).
+            SetLimit(maxTimeEntryExport+1),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch time entries", err)
+        return
+    }
+
+    entries := []models.TimeEntry{}
+    if err := cursor.All(ctx, &entries); err != nil {
+        respondWithError(c, 500, "Failed to fetch time entries", err)
+        return
+    }
+    if len(entries) > maxTimeEntryExport {
+        c.JSON(400, gin.H{"error": fmt.Sprintf("More than %d time entries match; narrow the date range", maxTimeEntryExport)})
+        return
+    }
+
+    var total int64
+    for _, entry := range entries {
+        total += entry.Duration
+    }
+
+    if format == "json" {
+        c.JSON(200, gin.H{"time_entries": entries, "total_duration": total})
+        return
+    }
+
+    titles, err := taskTitles(ctx, entries)
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch time entries", err)
+        return
+    }
+    writeTimeEntriesCSV(c, entries, titles, location)
+}
+
+// ------------------ Helper Functions ------------------
+
+// applyTo validates the payload and copies it onto the entry. On update,
+// fields left out keep their value and a new start keeps the duration.
+func (p timeEntryPayload) applyTo(entry *models.TimeEntry, partial bool) models.ValidationErrors {
+    var errs models.ValidationErrors
+
+    if p.StartedAt != nil {
+        entry.StartedAt = *p.StartedAt
+    } else if !partial {
+        errs = append(errs, models.FieldError{Field: "started_at", Message: "is required"})
+    }
+
+    switch {
+    case p.EndedAt != nil && p.Duration != nil:
+        errs = append(errs, models.FieldError{Field: "duration", Message: "cannot be combined with ended_at"})
+    case p.EndedAt != nil:
+        entry.Duration = int64(p.EndedAt.Sub(entry.StartedAt).Seconds())
+    case p.Duration != nil:
+        entry.Duration = *p.Duration
+    case !partial:
+        errs = append(errs, models.FieldError{Field: "ended_at", Message: "ended_at or duration is required"})
+    }
+    if errs == nil && (entry.Duration <= 0 || entry.Duration > int64(models.MaxTimeEntryDuration.Seconds())) {
+        errs = append(errs, models.FieldError{
+            Field:   "duration",
+            Message: fmt.Sprintf("must be between 1 second and %d hours", int(models.MaxTimeEntryDuration.Hours())),
+        })
+    }
+
+    ended := entry.StartedAt.Add(time.Duration(entry.Duration) * time.Second)
+    if ended.After(time.Now().Add(clockSkewAllowance)) {
+        errs = append(errs, models.FieldError{Field: "ended_at", Message: "cannot be in the future"})
+    }
+    entry.EndedAt = &ended
+
+    if p.Note != nil {
+        errs = append(errs, validateTimeEntryNote(*p.Note)...)
+        entry.Note = strings.TrimSpace(*p.Note)
+    }
+
+    if errs != nil {
+        return errs
+    }
+    return nil
+}
+
+func validateTimeEntryNote(note string) models.ValidationErrors {
+    if utf8.RuneCountInString(strings.TrimSpace(note)) > models.TimeEntryNoteMaxLength {
+        return models.ValidationErrors{{
+            Field:   "note",
+            Message: fmt.Sprintf("must be at most %d characters", models.TimeEntryNoteMaxLength),
+        }}
+    }
+    return nil
+}
+
+// runningTimer returns the user's running timer, or nil
+func runningTimer(ctx context.Context, userID primitive.ObjectID) (*models.TimeEntry, error) {
+    var entry models.TimeEntry
+    err := database.GetCollection(timeEntryCollection).
+        FindOne(ctx, bson.M{"user_id": userID, "running": true}).
+        Decode(&entry)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &entry, nil
+}
+
+// Fetch one of the user's time entries on a task
+func loadTimeEntryForUser(ctx context.Context, taskID, entryID, userID primitive.ObjectID) (*models.TimeEntry, error) {
+    var entry models.TimeEntry
+    err := database.GetCollection(timeEntryCollection).
+        FindOne(ctx, bson.M{"_id": entryID, "task_id": taskID, "user_id": userID}).
+        Decode(&entry)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, errTimeEntryNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &entry, nil
+}
+
+func respondWithTimeEntryLookupError(c *gin.Context, err error) {
+    if errors.Is(err, errTimeEntryNotFound) {
+        c.JSON(404, gin.H{"error": "Time entry not found"})
+        return
+    }
+    respondWithError(c, 500, "Failed to fetch time entry", err)
+}
+
+// addTimeSpent adjusts the task's total of tracked seconds. Like the
+// subtask roll-up it is derived data, so the task's version is left alone.
+func addTimeSpent(ctx context.Context, taskID primitive.ObjectID, seconds int64) {
+    if seconds == 0 {
+        return
+    }
+    if _, err := database.GetCollection(taskCollection).UpdateOne(ctx,
+        bson.M{"_id": taskID},
+        bson.M{"$inc": bson.M{"time_spent": seconds}},
+    ); err != nil {
+        log.Printf("Failed to update time spent on task %s: %v", taskID.Hex(), err)
+    }
+}
+
+// Remove every time entry on the given tasks
+func deleteTaskTimeEntries(ctx context.Context, taskIDs []primitive.ObjectID) error {
+    _, err := database.GetCollection(timeEntryCollection).DeleteMany(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}})
+    return err
+}
+
+// parseTimeBound reads an RFC3339 timestamp or a date in loc. endOfDay
+// turns a date into the start of the following day.
+func parseTimeBound(raw string, loc *time.Location, endOfDay bool) (time.Time, error) {
+    if t, err := time.Parse(time.RFC3339, raw); err == nil {
+        return t, nil
+    }
+    t, err := time.ParseInLocation("2006-01-02", raw, loc)
+    if err != nil {
+        return time.Time{}, err
+    }
+    if endOfDay {
+        t = t.AddDate(0, 0, 1)
+    }
+    return t, nil
+}
+
+// taskTitles looks up the titles of the entries' tasks, trashed ones
+// included since their time was still spent
+func taskTitles(ctx context.Context, entries []models.TimeEntry) (map[primitive.ObjectID]string, error) {
+    var ids []primitive.ObjectID
+    seen := make(map[primitive.ObjectID]bool)
+    for _, entry := range entries {
+        if !seen[entry.TaskID] {
+            seen[entry.TaskID] = true
+            ids = append(ids, entry.TaskID)
+        }
+    }
+
+    titles := make(map[primitive.ObjectID]string, len(ids))
+    if len(ids) == 0 {
+        return titles, nil
+    }
+    cursor, err := database.GetCollection(taskCollection).Find(ctx,
+        bson.M{"_id": bson.M{"$in": ids}},
+        options.Find().SetProjection(bson.M{"title": 1}),
+    )
+    if err != nil {
+        return nil, err
+    }
+    var tasks []models.Task
+    if err := cursor.All(ctx, &tasks); err != nil {
+        return nil, err
+    }
+    for _, task := range tasks {
+        titles[task.ID] = task.Title
+    }
+    return titles, nil
+}
+
+// writeTimeEntriesCSV sends the entries as a CSV attachment with one row
+// per entry and the duration in seconds and in decimal hours
+func writeTimeEntriesCSV(c *gin.Context, entries []models.TimeEntry, titles map[primitive.ObjectID]string, loc *time.Location) {
+    c.Header("Content-Type", "text/csv; charset=utf-8")
+    c.Header("Content-Disposition", `attachment; filename="time-entries.csv"`)
+    c.Status(200)
+
+    w := csv.NewWriter(c.Writer)
+    w.Write([]string{"date", "task_id", "task_title", "started_at", "ended_at", "duration_seconds", "hours", "note"})
+    for _, entry := range entries {
+        ended := ""
+        if entry.EndedAt != nil {
+            ended = entry.EndedAt.In(loc).Format(time.RFC3339)
+        }
+        started := entry.StartedAt.In(loc)
+        w.Write([]string{
+            started.Format("2006-01-02"),
+            entry.TaskID.Hex(),
+            csvSafe(titles[entry.TaskID]),
+            started.Format(time.RFC3339),
+            ended,
+            fmt.Sprintf("%d", entry.Duration),
+            fmt.Sprintf("%.2f", float64(entry.Duration)/3600),
+            csvSafe(entry.Note),
+        })
+    }
+    w.Flush()
+    if err := w.Error(); err != nil {
+        log.Printf("Failed to write time entries CSV: %v", err)
+    }
+}
+
+// csvSafe stops spreadsheets from reading user text as a formula
+func csvSafe(s string) string {
+    if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+        return "'" + s
+    }
+    return s
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+// purgeBatchSize bounds how many tasks one purge pass removes at a time
+const purgeBatchSize = 500
+
+// GetTrash lists the user's trashed tasks, most recently deleted first
+func GetTrash(c *gin.Context) {
+    userID := currentUserID(c)
+
+    limit, offset, err := parseSearchPaging(c)
+    if err != nil {
+        c.JSON(400, gin.H{"error": err.Error()})
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    cursor, err := database.GetCollection(taskCollection).Find(ctx,
+        bson.M{"created_by": userID, "deleted_at": bson.M{"$ne": nil}},
+        options.Find().
+            SetSort(bson.D{{Key: "deleted_at", Value: -1}, {Key: "_id", Value: -1}}).
+            SetSkip(offset).
+            SetLimit(limit),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch trash", err)
+        return
+    }
+
+    tasks := []models.Task{}
+    if err := cursor.All(ctx, &tasks); err != nil {
+        respondWithError(c, 500, "Failed to fetch trash", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"tasks": tasks, "limit": limit, "offset": offset})
+}
+
+// RestoreTask takes a task out of the trash together with the subtasks that
+// were deleted with it. A task whose parent is still in the trash comes
+// back as a top-level task.
+func RestoreTask(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    collection := database.GetCollection(taskCollection)
+    filter := bson.M{"_id": taskID, "created_by": userID, "deleted_at": bson.M{"$ne": nil}}
+
+    var task models.Task
+    err := collection.FindOne(ctx, filter).Decode(&task)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        c.JSON(404, gin.H{"error": "Task not found in trash"})
+        return
+    }
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch task", err)
+        return
+    }
+
+    unset := bson.M{"deleted_at": "", "deleted_by": "", "deleted_with": ""}
+    if task.ParentID != nil {
+        live, err := collection.CountDocuments(ctx, bson.M{"_id": *task.ParentID, "deleted_at": nil})
+        if err != nil {
+            respondWithError(c, 500, "Failed to restore task", err)
+            return
+        }
+        if live == 0 {
+            unset["parent_id"] = ""
+            task.ParentID = nil
+        }
+    }
+
+    matchedCount, err := updateTask(filter, bson.M{"$unset": unset, "$set": bson.M{"updated_at": time.Now()}})
+    if err != nil {
+        respondWithError(c, 500, "Failed to restore task", err)
+        return
+    }
+    if matchedCount == 0 {
+        c.JSON(404, gin.H{"error": "Task not found in trash"})
+        return
+    }
+
+    restored, err := restoreDeletedWith(ctx, task.ID)
+    if err != nil {
+        respondWithError(c, 500, "Failed to restore subtasks", err)
+        return
+    }
+
+    entries := []models.Activity{newActivity(task.ID, userID, models.ActivityTaskRestored)}
+    for _, id := range restored {
+        entries = append(entries, newActivity(id, userID, models.ActivityTaskRestored))
+    }
+    recordActivity(ctx, entries...)
+    // Walks up from the task, so its parent's roll-up is refreshed as well
+    refreshSubtaskRollups(ctx, &task.ID)
+
+    c.JSON(200, gin.H{"message": "Task restored successfully", "restored_subtasks": len(restored)})
+}
+
+// PurgeTrashedTasks permanently removes tasks that have been in the trash
+// longer than retention, along with their comments, time entries,
+// attachment files and the dependency edges pointing at them. It returns
+// the number of tasks removed.
+func PurgeTrashedTasks(ctx context.Context, retention time.Duration) (int64, error) {
+    collection := database.GetCollection(taskCollection)
+    cutoff := time.Now().Add(-retention)
+
+    var purged int64
+    for {
+        cursor, err := collection.Find(ctx,
+            bson.M{"deleted_at": bson.M{"$lt": cutoff}},
+            options.Find().SetProjection(bson.M{"_id": 1, "attachments": 1}).SetLimit(purgeBatchSize),
+        )
+        if err != nil {
+            return purged, err
+        }
+
+        var batch []struct {
+            ID          primitive.ObjectID  `bson:"_id"`
+            Attachments []models.Attachment `bson:"attachments"`
+        }
+        if err := cursor.All(ctx, &batch); err != nil {
+            return purged, err
+        }
+        if len(batch) == 0 {
+            return purged, nil
+        }
+
+        ids := make([]primitive.ObjectID, len(batch))
+        for i, t := range batch {
+            ids[i] = t.ID
+        }
+
+        // Clean up what points at the tasks first, so a failure part way
+        // leaves them in the trash to be retried on the next pass
+        if err := releaseDependents(ctx, ids); err != nil {
+            return purged, err
+        }
+        if err := deleteTaskComments(ctx, ids); err != nil {
+            return purged, err
+        }
+        if err := deleteTaskTimeEntries(ctx, ids); err != nil {
+            return purged, err
+        }
+        for _, t := range batch {
+            if err := deleteAttachmentFiles(ctx, t.Attachments); err != nil {
+                return purged, err
+            }
+        }
+        orphan := bson.M{"$unset": bson.M{"parent_id": ""}}
+        bumpVersion(orphan)
+        if _, err := collection.UpdateMany(ctx,
+            bson.M{"parent_id": bson.M{"$in": ids}, "deleted_at": nil},
+            orphan,
+        ); err != nil {
+            return purged, err
+        }
+
+        result, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "deleted_at": bson.M{"$lt": cutoff}})
+        if err != nil {
+            return purged, err
+        }
+        purged += result.DeletedCount
+
+        if len(batch) < purgeBatchSize {
+            return purged, nil
+        }
+    }
+}
+
+// ------------------ Helper Functions ------------------
+
+// Restore the tasks that were trashed along with the given task and return
+// their IDs
+func restoreDeletedWith(ctx context.Context, taskID primitive.ObjectID) ([]primitive.ObjectID, error) {
+    collection := database.GetCollection(taskCollection)
+    filter := bson.M{"deleted_with": taskID}
+
+    cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+    if err != nil {
+        return nil, err
+    }
+    var found []struct {
+        ID primitive.ObjectID `bson:"_id"`
+    }
+    if err := cursor.All(ctx, &found); err != nil {
+        return nil, err
+    }
+    if len(found) == 0 {
+        return nil, nil
+    }
+
+    update := bson.M{
+        "$unset": bson.M{"deleted_at": "", "deleted_by": "", "deleted_with": ""},
+        "$set":   bson.M{"updated_at": time.Now()},
+    }
+    bumpVersion(update)
+    if _, err := collection.UpdateMany(ctx, filter, update); err != nil {
+        return nil, err
+    }
+
+    ids := make([]primitive.ObjectID, len(found))
+    for i, t := range found {
+        ids[i] = t.ID
+    }
+    return ids, nil
+}
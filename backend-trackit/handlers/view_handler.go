@@ -0,0 +1,485 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/url"
+    "sort"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+)
+
+const viewCollection = "views"
+
+// errViewNotFound is returned when a view does not exist or the user can't
+// see it
+var errViewNotFound = errors.New("view not found")
+
+// viewFilterParams are the GET /api/tasks parameters a view may store.
+// Custom field filters (cf.<key>) are allowed as well.
+var viewFilterParams = map[string]bool{
+    "status":         true,
+    "priority":       true,
+    "tags":           true,
+    "tags_mode":      true,
+    "assignee":       true,
+    "due_before":     true,
+    "due_after":      true,
+    "created_before": true,
+    "created_after":  true,
+    "updated_before": true,
+    "updated_after":  true,
+    "archived":       true,
+}
+
+// smartView is a built-in view. filters works out its query parameters when
+// it runs, at now in the viewer's time zone.
+type smartView struct {
+    models.SmartView
+    filters func(now time.Time, loc *time.Location) url.Values
+}
+
+var smartViews = []smartView{
+    {
+        SmartView: models.SmartView{ID: "overdue", Name: "Overdue", Sort: "due_date"},
+        filters: func(now time.Time, loc *time.Location) url.Values {
+            return url.Values{
+                "status":     {strings.Join(openStatuses(), ",")},
+                "due_before": {now.Format(time.RFC3339Nano)},
+            }
+        },
+    },
+    {
+        SmartView: models.SmartView{ID: "due-this-week", Name: "Due this week", Sort: "due_date"},
+        filters: func(now time.Time, loc *time.Location) url.Values {
+            start, end := weekBounds(now, loc)
+            return url.Values{
+                "status":     {strings.Join(openStatuses(), ",")},
+                "due_after":  {start.Format(time.RFC3339Nano)},
+                "due_before": {end.Add(-time.Nanosecond).Format(time.RFC3339Nano)},
+            }
+        },
+    },
+    {
+        SmartView: models.SmartView{ID: "assigned-to-me-not-started", Name: "Assigned to me, not started", Sort: "due_date"},
+        filters: func(now time.Time, loc *time.Location) url.Values {
+            return url.Values{
+                "assignee": {"me"},
+                "status":   {models.StatusTodo},
+            }
+        },
+    },
+}
+
+// GetViews lists the user's own views, the views others shared, and the
+// built-in smart views
+func GetViews(c *gin.Context) {
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    cursor, err := database.GetCollection(viewCollection).Find(ctx,
+        bson.M{"$or": []bson.M{{"created_by": userID}, {"shared": true}}},
+        options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}),
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch views", err)
+        return
+    }
+    views := []models.SavedView{}
+    if err := cursor.All(ctx, &views); err != nil {
+        respondWithError(c, 500, "Failed to fetch views", err)
+        return
+    }
+
+    builtin := make([]models.SmartView, len(smartViews))
+    for i, view := range smartViews {
+        builtin[i] = view.SmartView
+        builtin[i].Builtin = true
+    }
+
+    c.JSON(200, gin.H{"views": views, "smart_views": builtin})
+}
+
+// CreateView saves a named filter, sort and column set
+func CreateView(c *gin.Context) {
+    userID := currentUserID(c)
+
+    var input viewPayload
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    now := time.Now()
+    view := models.SavedView{
+        ID:        primitive.NewObjectID(),
+        Filters:   map[string]string{},
+        CreatedBy: userID,
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    input.applyTo(&view)
+    if errs, err := validateView(ctx, &view); err != nil {
+        respondWithError(c, 500, "Failed to create view", err)
+        return
+    } else if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    collection := database.GetCollection(viewCollection)
+    count, err := collection.CountDocuments(ctx, bson.M{"created_by": userID})
+    if err != nil {
+        respondWithError(c, 500, "Failed to create view", err)
+        return
+    }
+    if count >= models.MaxViewsPerUser {
+        c.JSON(400, gin.H{"error": fmt.Sprintf("A user can have at most %d views", models.MaxViewsPerUser)})
+        return
+    }
+
+    if _, err := collection.InsertOne(ctx, view); err != nil {
+        respondWithError(c, 500, "Failed to create view", err)
+        return
+    }
+
+    c.JSON(201, gin.H{"message": "View created successfully", "view": view})
+}
+
+// GetView returns a saved or smart view
+func GetView(c *gin.Context) {
+    if smart := findSmartView(c.Param("id")); smart != nil {
+        view := smart.SmartView
+        view.Builtin = true
+        c.JSON(200, gin.H{"view": view})
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    view, err := loadViewForUser(ctx, c.Param("id"), currentUserID(c))
+    if err != nil {
+        respondWithViewLookupError(c, err)
+        return
+    }
+    c.JSON(200, gin.H{"view": view})
+}
+
+// UpdateView changes a saved view. Only its creator may change it.
+func UpdateView(c *gin.Context) {
+    userID := currentUserID(c)
+
+    var input viewPayload
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    view, err := loadEditableView(c, ctx, userID)
+    if err != nil {
+        return
+    }
+
+    input.applyTo(view)
+    if errs, err := validateView(ctx, view); err != nil {
+        respondWithError(c, 500, "Failed to update view", err)
+        return
+    } else if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    view.UpdatedAt = time.Now()
+    result, err := database.GetCollection(viewCollection).ReplaceOne(ctx,
+        bson.M{"_id": view.ID, "created_by": userID},
+        view,
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to update view", err)
+        return
+    }
+    if result.MatchedCount == 0 {
+        c.JSON(404, gin.H{"error": "View not found"})
+        return
+    }
+
+    c.JSON(200, gin.H{"message": "View updated successfully", "view": view})
+}
+
+// DeleteView removes a saved view. Only its creator may delete it.
+func DeleteView(c *gin.Context) {
+    userID := currentUserID(c)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    view, err := loadEditableView(c, ctx, userID)
+    if err != nil {
+        return
+    }
+
+    if _, err := database.GetCollection(viewCollection).DeleteOne(ctx, bson.M{"_id": view.ID, "created_by": userID}); err != nil {
+        respondWithError(c, 500, "Failed to delete view", err)
+        return
+    }
+    c.JSON(200, gin.H{"message": "View deleted successfully"})
+}
+
+// GetViewTasks runs a saved or smart view and returns a page of its tasks,
+// taking limit and cursor like GET /api/tasks. Smart views that depend on
+// the date use the timezone parameter, UTC by default.
+func GetViewTasks(c *gin.Context) {
+    userID := currentUserID(c)
+
+    loc, err := time.LoadLocation(c.DefaultQuery("timezone", "UTC"))
+    if err != nil {
+        c.JSON(400, gin.H{"error": fmt.Sprintf("invalid timezone %q", c.Query("timezone"))})
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var view interface{}
+    var params url.Values
+    if smart := findSmartView(c.Param("id")); smart != nil {
+        params = smart.filters(time.Now(), loc)
+        params.Set("sort", smart.Sort)
+        builtin := smart.SmartView
+        builtin.Builtin = true
+        view = builtin
+    } else {
+        saved, err := loadViewForUser(ctx, c.Param("id"), userID)
+        if err != nil {
+            respondWithViewLookupError(c, err)
+            return
+        }
+        params = viewQueryParams(saved)
+        view = saved
+    }
+    for _, param := range []string{"limit", "cursor"} {
+        if value := c.Query(param); value != "" {
+            params.Set(param, value)
+        }
+    }
+
+    customFields, err := loadCustomFields(ctx)
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch custom fields", err)
+        return
+    }
+    query, err := parseTaskQuery(params, userID, customFields)
+    if err != nil {
+        // Saved filters can go stale, e.g. when a custom field is deleted
+        c.JSON(400, gin.H{"error": err.Error()})
+        return
+    }
+
+    tasks, nextCursor, err := fetchUserTasks(query)
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch tasks", err)
+        return
+    }
+    for i := range tasks {
+        tasks[i].FillChecklistRatio()
+    }
+
+    c.JSON(200, gin.H{"view": view, "tasks": tasks, "next_cursor": nextCursor})
+}
+
+// ------------------ Helper Functions ------------------
+
+// viewPayload is a create or update body; fields left out are unchanged
+type viewPayload struct {
+    Name    *string           `json:"name"`
+    Filters map[string]string `json:"filters"`
+    Sort    *string           `json:"sort"`
+    Columns *[]string         `json:"columns"`
+    Shared  *bool             `json:"shared"`
+}
+
+func (p viewPayload) applyTo(view *models.SavedView) {
+    if p.Name != nil {
+        view.Name = strings.TrimSpace(*p.Name)
+    }
+    if p.Filters != nil {
+        view.Filters = p.Filters
+    }
+    if p.Sort != nil {
+        view.Sort = strings.TrimSpace(*p.Sort)
+    }
+    if p.Columns != nil {
+        view.Columns = *p.Columns
+    }
+    if p.Shared != nil {
+        view.Shared = *p.Shared
+    }
+}
+
+// validateView checks a view's name, columns, and that its filters and sort
+// make a valid task query
+func validateView(ctx context.Context, view *models.SavedView) (models.ValidationErrors, error) {
+    customFields, err := loadCustomFields(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    var errs models.ValidationErrors
+    if view.Name == "" {
+        errs = append(errs, models.FieldError{Field: "name", Message: "cannot be empty"})
+    } else if utf8.RuneCountInString(view.Name) > models.ViewNameMaxLength {
+        errs = append(errs, models.FieldError{Field: "name", Message: fmt.Sprintf("must be at most %d characters", models.ViewNameMaxLength)})
+    }
+
+    filterErrs := false
+    for param := range view.Filters {
+        if !viewFilterParams[param] && !strings.HasPrefix(param, customFieldParamPrefix) {
+            errs = append(errs, models.FieldError{Field: "filters", Message: fmt.Sprintf("unknown filter %q", param)})
+            filterErrs = true
+        }
+    }
+    if !filterErrs {
+        filters := viewQueryParams(&models.SavedView{Filters: view.Filters})
+        if _, err := parseTaskQuery(filters, primitive.NilObjectID, customFields); err != nil {
+            errs = append(errs, models.FieldError{Field: "filters", Message: err.Error()})
+        }
+    }
+    if view.Sort != "" {
+        if _, err := parseTaskQuery(url.Values{"sort": {view.Sort}}, primitive.NilObjectID, customFields); err != nil {
+            errs = append(errs, models.FieldError{Field: "sort", Message: err.Error()})
+        }
+    }
+
+    if len(view.Columns) > models.MaxViewColumns {
+        errs = append(errs, models.FieldError{Field: "columns", Message: fmt.Sprintf("must contain at most %d columns", models.MaxViewColumns)})
+    }
+    known := getJSONToBSONMap(models.Task{})
+    seen := make(map[string]bool)
+    for _, column := range view.Columns {
+        key, custom := strings.CutPrefix(column, customFieldParamPrefix)
+        _, defined := customFields[key]
+        switch {
+        case seen[column]:
+            errs = append(errs, models.FieldError{Field: "columns", Message: fmt.Sprintf("duplicate column %q", column)})
+        case custom && !defined, !custom && known[column] == "" && column != "checklist_ratio":
+            errs = append(errs, models.FieldError{Field: "columns", Message: fmt.Sprintf("unknown column %q", column)})
+        }
+        seen[column] = true
+    }
+
+    if len(errs) > 0 {
+        sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+        return errs, nil
+    }
+    return nil, nil
+}
+
+// viewQueryParams turns a saved view into GET /api/tasks query parameters
+func viewQueryParams(view *models.SavedView) url.Values {
+    params := url.Values{}
+    for param, value := range view.Filters {
+        params.Set(param, value)
+    }
+    if view.Sort != "" {
+        params.Set("sort", view.Sort)
+    }
+    return params
+}
+
+// Load a saved view the user created or that is shared
+func loadViewForUser(ctx context.Context, rawID string, userID primitive.ObjectID) (*models.SavedView, error) {
+    viewID, err := primitive.ObjectIDFromHex(rawID)
+    if err != nil {
+        return nil, errViewNotFound
+    }
+
+    var view models.SavedView
+    err = database.GetCollection(viewCollection).FindOne(ctx, bson.M{
+        "_id": viewID,
+        "$or": []bson.M{{"created_by": userID}, {"shared": true}},
+    }).Decode(&view)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, errViewNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &view, nil
+}
+
+// Load the view named in the path for a change by its creator. A non-nil
+// error means a response has already been sent.
+func loadEditableView(c *gin.Context, ctx context.Context, userID primitive.ObjectID) (*models.SavedView, error) {
+    if findSmartView(c.Param("id")) != nil {
+        c.JSON(403, gin.H{"error": "Built-in views cannot be changed"})
+        return nil, errViewNotFound
+    }
+    view, err := loadViewForUser(ctx, c.Param("id"), userID)
+    if err != nil {
+        respondWithViewLookupError(c, err)
+        return nil, err
+    }
+    if view.CreatedBy != userID {
+        c.JSON(403, gin.H{"error": "Only the view's creator can change it"})
+        return nil, errViewNotFound
+    }
+    return view, nil
+}
+
+// Respond to a failed loadViewForUser
+func respondWithViewLookupError(c *gin.Context, err error) {
+    if errors.Is(err, errViewNotFound) {
+        c.JSON(404, gin.H{"error": "View not found"})
+        return
+    }
+    respondWithError(c, 500, "Failed to fetch view", err)
+}
+
+func findSmartView(id string) *smartView {
+    for i := range smartViews {
+        if smartViews[i].ID == id {
+            return &smartViews[i]
+        }
+    }
+    return nil
+}
+
+// openStatuses lists the statuses of tasks that still need work
+func openStatuses() []string {
+    var open []string
+    for _, status := range models.TaskStatuses {
+        if status != models.StatusDone && status != models.StatusCancelled {
+            open = append(open, status)
+        }
+    }
+    return open
+}
+
+// weekBounds returns the start of the Monday-based week containing now, in
+// loc, and the start of the following week
+func weekBounds(now time.Time, loc *time.Location) (time.Time, time.Time) {
+    local := now.In(loc)
+    daysSinceMonday := (int(local.Weekday()) + 6) % 7
+    start := time.Date(local.Year(), local.Month(), local.Day()-daysSinceMonday, 0, 0, 0, 0, loc)
+    return start, start.AddDate(0, 0, 7)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+    "backend-trackit/models"
+    "backend-trackit/services"
+)
+
+const workflowCollection = "workflows"
+
+// GetWorkflow returns the status workflow tasks currently follow
+func GetWorkflow(c *gin.Context) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    workflow, err := loadWorkflow(ctx)
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch workflow", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"workflow": workflow})
+}
+
+// UpdateWorkflow replaces the status workflow. Admins only. States that
+// tasks are still in cannot be dropped.
+func UpdateWorkflow(c *gin.Context) {
+    var input struct {
+        InitialState string                      `json:"initial_state"`
+        States       []string                    `json:"states"`
+        Transitions  []models.WorkflowTransition `json:"transitions"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    workflow := models.Workflow{
+        Name:         models.DefaultWorkflowName,
+        InitialState: input.InitialState,
+        States:       input.States,
+        Transitions:  input.Transitions,
+        UpdatedBy:    currentUserID(c),
+        UpdatedAt:    time.Now(),
+    }
+    if errs := workflow.Validate(); errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    // Trashed tasks count too, since they can be restored
+    inUse, err := database.GetCollection(taskCollection).Distinct(ctx, "status",
+        bson.M{"status": bson.M{"$nin": workflow.States}},
+    )
+    if err != nil {
+        respondWithError(c, 500, "Failed to save workflow", err)
+        return
+    }
+    if len(inUse) > 0 {
+        c.JSON(409, gin.H{"error": "States still used by tasks cannot be removed", "states": inUse})
+        return
+    }
+
+    collection := database.GetCollection(workflowCollection)
+    opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+    if err := collection.FindOneAndReplace(ctx, bson.M{"name": workflow.Name}, workflow, opts).Decode(&workflow); err != nil {
+        respondWithError(c, 500, "Failed to save workflow", err)
+        return
+    }
+
+    c.JSON(200, gin.H{"message": "Workflow updated successfully", "workflow": workflow})
+}
+
+// TransitionTask moves a task to another status following the workflow
+func TransitionTask(c *gin.Context) {
+    taskID, _ := primitive.ObjectIDFromHex(c.Param("id"))
+    userID := currentUserID(c)
+
+    var input struct {
+        To      string `json:"to" binding:"required"`
+        Comment string `json:"comment"`
+    }
+    if err := c.ShouldBindJSON(&input); err != nil {
+        respondWithError(c, 400, "Invalid request payload", err)
+        return
+    }
+
+    // Reuse the task schema so legacy aliases and enum errors match UpdateTask
+    validated, errs := models.ValidateTaskInput(map[string]interface{}{"status": input.To}, true)
+    if errs != nil {
+        respondWithValidationErrors(c, errs)
+        return
+    }
+    to := validated.Set["status"].(string)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    task, role, err := loadTaskForUser(ctx, taskID, userID)
+    if err != nil {
+        respondWithTaskLookupError(c, err)
+        return
+    }
+    version, ok := checkIfMatch(c, task)
+    if !ok {
+        return
+    }
+
+    change := newTaskChange(task, role, userID, validated)
+    change.Comment = input.Comment
+    change.Version = version
+    if err := applyTaskChange(ctx, change); err != nil {
+        respondWithTaskUpdateError(c, err)
+        return
+    }
+
+    workflow, err := loadWorkflow(ctx)
+    if err != nil {
+        respondWithError(c, 500, "Failed to fetch workflow", err)
+        return
+    }
+
+    c.JSON(200, gin.H{
+        "message":             "Task status updated successfully",
+        "transition":          change.Set["last_transition"],
+        "allowed_next_states": workflow.NextStates(to),
+    })
+}
+
+// ------------------ Helper Functions ------------------
+
+// Load the stored workflow, falling back to the built-in default
+func loadWorkflow(ctx context.Context) (models.Workflow, error) {
+    var workflow models.Workflow
+    err := database.GetCollection(workflowCollection).
+        FindOne(ctx, bson.M{"name": models.DefaultWorkflowName}).
+        Decode(&workflow)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return models.DefaultWorkflow(), nil
+    }
+    return workflow, err
+}
+
+// Respond to a refused status change
+func respondWithTransitionError(c *gin.Context, err error) {
+    status, body := transitionErrorResponse(err)
+    if status == 500 {
+        respondWithError(c, 500, "Failed to check status transition", err)
+        return
+    }
+    c.JSON(status, body)
+}
+
+// Build the response for a refused status change; illegal moves and missing
+// assignees are conflicts with the task's state, a missing comment is bad input
+func transitionErrorResponse(err error) (int, gin.H) {
+    var transitionErr *services.TransitionError
+    if !errors.As(err, &transitionErr) {
+        return 500, gin.H{"error": "Failed to check status transition"}
+    }
+
+    status := 409
+    if transitionErr.Reason == services.TransitionCommentRequired {
+        status = 400
+    }
+    return status, gin.H{
+        "error":               transitionErr.Error(),
+        "reason":              transitionErr.Reason,
+        "from":                transitionErr.From,
+        "to":                  transitionErr.To,
+        "allowed_next_states": transitionErr.Allowed,
+    }
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+    "context"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "backend-trackit/database"
+)
+
+const leaseCollection = "locks"
+
+// acquireLease takes or renews the named lease for owner until ttl from now.
+// It reports false while another owner holds an unexpired lease.
+func acquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+    now := time.Now()
+    _, err := database.GetCollection(leaseCollection).UpdateOne(ctx,
+        bson.M{
+            "_id": name,
+            "$or": bson.A{
+                bson.M{"owner": owner},
+                bson.M{"expires_at": bson.M{"$lt": now}},
+            },
+        },
+        bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(ttl)}},
+        options.Update().SetUpsert(true),
+    )
+    // The upsert collides with the existing lease when someone else holds it
+    if mongo.IsDuplicateKeyError(err) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// releaseLease gives up the named lease if owner still holds it, so another
+// replica can take over without waiting for it to expire
+func releaseLease(ctx context.Context, name, owner string) error {
+    _, err := database.GetCollection(leaseCollection).DeleteOne(ctx, bson.M{"_id": name, "owner": owner})
+    return err
+}
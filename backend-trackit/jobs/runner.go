@@ -0,0 +1,110 @@
+package jobs
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "log"
+    "os"
+    "sync"
+    "time"
+)
+
+// Job is background work that runs on a fixed interval
+type Job struct {
+    Name     string
+    Interval time.Duration
+    Run      func(ctx context.Context) error
+
+    // Lease, when set, makes the job exclusive across replicas: a run only
+    // starts while this process holds the job's lease in Mongo, which is
+    // taken or renewed for Lease before each run
+    Lease time.Duration
+}
+
+// Runner runs jobs in their own goroutines until it is stopped
+type Runner struct {
+    jobs   []Job
+    owner  string
+    cancel context.CancelFunc
+    wg     sync.WaitGroup
+}
+
+func NewRunner(jobs ...Job) *Runner {
+    return &Runner{jobs: jobs, owner: instanceID()}
+}
+
+// Start runs every job once right away and then on its interval
+func (r *Runner) Start() {
+    ctx, cancel := context.WithCancel(context.Background())
+    r.cancel = cancel
+
+    for _, job := range r.jobs {
+        r.wg.Add(1)
+        go func(job Job) {
+            defer r.wg.Done()
+            r.loop(ctx, job)
+        }(job)
+    }
+}
+
+// Stop cancels running jobs, waits for them to return and gives up the
+// leases they held
+func (r *Runner) Stop() {
+    if r.cancel == nil {
+        return
+    }
+    r.cancel()
+    r.wg.Wait()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    for _, job := range r.jobs {
+        if job.Lease == 0 {
+            continue
+        }
+        if err := releaseLease(ctx, job.Name, r.owner); err != nil {
+            log.Printf("Failed to release lease for job %s: %v", job.Name, err)
+        }
+    }
+}
+
+func (r *Runner) loop(ctx context.Context, job Job) {
+    ticker := time.NewTicker(job.Interval)
+    defer ticker.Stop()
+
+    for {
+        if err := r.run(ctx, job); err != nil && ctx.Err() == nil {
+            log.Printf("Job %s failed: %v", job.Name, err)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// run runs the job once, if this process may
+func (r *Runner) run(ctx context.Context, job Job) error {
+    if job.Lease > 0 {
+        held, err := acquireLease(ctx, job.Name, r.owner, job.Lease)
+        if err != nil {
+            return fmt.Errorf("acquire lease: %w", err)
+        }
+        if !held {
+            return nil
+        }
+    }
+    return job.Run(ctx)
+}
+
+// instanceID names this process in the leases it holds
+func instanceID() string {
+    host, _ := os.Hostname()
+    suffix := make([]byte, 4)
+    rand.Read(suffix)
+    return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
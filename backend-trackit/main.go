@@ -13,8 +13,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"backend-trackit/config"
 	"backend-trackit/database"
+	"backend-trackit/handlers"
+	"backend-trackit/jobs"
 	"backend-trackit/middleware"
 	"backend-trackit/routes"
+	"backend-trackit/services"
 )
 
 func main() {
@@ -24,6 +27,16 @@ func main() {
 	// Initialize database connection
 	database.InitDatabase()
 
+	// Choose where attachment contents are stored
+	storage, err := newAttachmentStorage(config.AttachmentStorage())
+	if err != nil {
+		log.Fatalf("Failed to set up attachment storage: %v", err)
+	}
+	handlers.SetAttachmentStorage(storage)
+
+	// Start the websocket hub that fans out real-time events
+	go services.WebsocketHub.Run()
+
 	// Initialize Gin Router
 	r := gin.Default()
 
@@ -41,12 +54,102 @@ func main() {
 		})
 	})
 
+	// Start background jobs; they are stopped once the server has shut down
+	background := []jobs.Job{
+		purgeTrashJob(config.TrashRetention()),
+		recurringTasksJob(),
+		rebalanceRanksJob(),
+	}
+	if after := config.ArchiveAfter(); after > 0 {
+		background = append(background, archiveDoneJob(after))
+	}
+	runner := jobs.NewRunner(background...)
+	runner.Start()
+
 	// Start server with graceful shutdown handling
-	startServer(r)
+	startServer(r, runner.Stop)
+}
+
+// newAttachmentStorage builds the attachment storage named by kind
+func newAttachmentStorage(kind string) (services.FileStorage, error) {
+	switch kind {
+	case "local":
+		return services.NewLocalStorage(config.AttachmentDir())
+	case "gridfs":
+		return services.NewGridFSStorage(database.DB, "attachments")
+	}
+	return nil, fmt.Errorf("unknown ATTACHMENT_STORAGE %q, expected local or gridfs", kind)
+}
+
+// purgeTrashJob permanently removes tasks that outlived the trash retention
+func purgeTrashJob(retention time.Duration) jobs.Job {
+	return jobs.Job{
+		Name:     "purge-trash",
+		Interval: time.Hour,
+		Lease:    10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			purged, err := handlers.PurgeTrashedTasks(ctx, retention)
+			if purged > 0 {
+				log.Printf("Purged %d tasks from the trash", purged)
+			}
+			return err
+		},
+	}
+}
+
+// archiveDoneJob archives tasks that have been done for longer than after
+func archiveDoneJob(after time.Duration) jobs.Job {
+	return jobs.Job{
+		Name:     "archive-done",
+		Interval: time.Hour,
+		Lease:    10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			archived, err := handlers.ArchiveCompletedTasks(ctx, after)
+			if archived > 0 {
+				log.Printf("Archived %d completed tasks", archived)
+			}
+			return err
+		},
+	}
 }
 
-// startServer initializes and starts the HTTP server with graceful shutdown
-func startServer(router *gin.Engine) {
+// recurringTasksJob creates the tasks for recurring tasks that have come
+// due. The lease keeps it to one replica at a time.
+func recurringTasksJob() jobs.Job {
+	return jobs.Job{
+		Name:     "recurring-tasks",
+		Interval: time.Minute,
+		Lease:    2 * time.Minute,
+		Run: func(ctx context.Context) error {
+			created, err := handlers.RunDueRecurringTasks(ctx, time.Now())
+			if created > 0 {
+				log.Printf("Created %d recurring tasks", created)
+			}
+			return err
+		},
+	}
+}
+
+// rebalanceRanksJob respaces board ranks that have grown long from repeated
+// moves into the same gap
+func rebalanceRanksJob() jobs.Job {
+	return jobs.Job{
+		Name:     "rebalance-ranks",
+		Interval: time.Hour,
+		Lease:    10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			changed, err := handlers.RebalanceBoardRanks(ctx)
+			if changed > 0 {
+				log.Printf("Rebalanced the board ranks of %d tasks", changed)
+			}
+			return err
+		},
+	}
+}
+
+// startServer initializes and starts the HTTP server with graceful shutdown.
+// onShutdown runs once the server has stopped taking requests.
+func startServer(router *gin.Engine, onShutdown ...func()) {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "10000" // Default port
@@ -77,7 +180,11 @@ func startServer(router *gin.Engine) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := server.Shutdown(ctx); err != nil {
+	err := server.Shutdown(ctx)
+	for _, stop := range onShutdown {
+		stop()
+	}
+	if err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
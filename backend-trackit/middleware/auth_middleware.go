@@ -8,6 +8,8 @@ import (
 
     "github.com/gin-gonic/gin"
     "github.com/golang-jwt/jwt/v4"
+
+    "backend-trackit/config"
 )
 
 type Claims struct {
@@ -20,8 +22,9 @@ func CORSMiddleware() gin.HandlerFunc {
         headers := c.Writer.Header()
         headers.Set("Access-Control-Allow-Origin", "*")
         headers.Set("Access-Control-Allow-Credentials", "true")
-        headers.Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-        headers.Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+        headers.Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, If-Match")
+        headers.Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
+        headers.Set("Access-Control-Expose-Headers", "ETag")
 
         if c.Request.Method == "OPTIONS" {
             c.AbortWithStatus(204)
@@ -98,4 +101,17 @@ func AuthMiddleware() gin.HandlerFunc {
         c.Set("userId", claims.UserId)
         c.Next()
     }
-}
\ No newline at end of file
+}
+
+// AdminMiddleware limits a route to the users config.IsAdmin accepts. It
+// must run after AuthMiddleware.
+func AdminMiddleware() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if !config.IsAdmin(c.GetString("userId")) {
+            c.JSON(403, gin.H{"error": "Admin access required"})
+            c.Abort()
+            return
+        }
+        c.Next()
+    }
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Activity types recorded in a task's history
+const (
+    ActivityTaskCreated       = "task_created"
+    ActivityTaskUpdated       = "task_updated"
+    ActivityTaskDeleted       = "task_deleted"
+    ActivityTaskRestored      = "task_restored"
+    ActivityTaskArchived      = "task_archived"
+    ActivityTaskUnarchived    = "task_unarchived"
+    ActivityTaskAssigned      = "task_assigned"
+    ActivityTaskUnassigned    = "task_unassigned"
+    ActivityCommentAdded      = "comment_added"
+    ActivityCommentEdited     = "comment_edited"
+    ActivityCommentDeleted    = "comment_deleted"
+    ActivityChecklistUpdated  = "checklist_updated"
+    ActivityAttachmentAdded   = "attachment_added"
+    ActivityAttachmentDeleted = "attachment_deleted"
+)
+
+// Activity is one entry in a task's append-only history. A zero ActorID
+// means the server made the change on its own, such as auto-archiving.
+type Activity struct {
+    ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+    TaskID    primitive.ObjectID  `bson:"task_id" json:"task_id"`
+    ActorID   primitive.ObjectID  `bson:"actor_id" json:"actor_id"`
+    Type      string              `bson:"type" json:"type"`
+    Changes   []FieldChange       `bson:"changes,omitempty" json:"changes,omitempty"`
+    CommentID *primitive.ObjectID `bson:"comment_id,omitempty" json:"comment_id,omitempty"`
+    CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// FieldChange records the value of a task field before and after an edit.
+// A nil From or To means the field was not set.
+type FieldChange struct {
+    Field string      `bson:"field" json:"field"`
+    From  interface{} `bson:"from" json:"from"`
+    To    interface{} `bson:"to" json:"to"`
+}
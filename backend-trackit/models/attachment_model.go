@@ -0,0 +1,39 @@
+package models
+
+import (
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limits applied to task attachments. The size limit is configured with
+// ATTACHMENT_MAX_MB.
+const (
+    MaxAttachmentsPerTask       = 50
+    AttachmentFilenameMaxLength = 255
+)
+
+// AttachmentTypes lists the content types attachments may have, as sniffed
+// from their first bytes
+var AttachmentTypes = []string{
+    "application/pdf",
+    "application/zip",
+    "application/x-gzip",
+    "image/gif",
+    "image/jpeg",
+    "image/png",
+    "image/webp",
+    "text/plain",
+}
+
+// Attachment describes a file attached to a task. The contents live in the
+// attachment storage under StorageKey.
+type Attachment struct {
+    ID          primitive.ObjectID `bson:"id" json:"id"`
+    Filename    string             `bson:"filename" json:"filename"`
+    ContentType string             `bson:"content_type" json:"content_type"`
+    Size        int64              `bson:"size" json:"size"` // bytes
+    StorageKey  string             `bson:"storage_key" json:"-"`
+    UploadedBy  primitive.ObjectID `bson:"uploaded_by" json:"uploaded_by"`
+    UploadedAt  time.Time          `bson:"uploaded_at" json:"uploaded_at"`
+}
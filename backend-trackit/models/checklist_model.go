@@ -0,0 +1,42 @@
+package models
+
+import (
+    "math"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limits applied to task checklists
+const (
+    MaxChecklistItems      = 100
+    ChecklistItemMaxLength = 500
+)
+
+// ChecklistItem is one line of a task's checklist
+type ChecklistItem struct {
+    ID        primitive.ObjectID  `bson:"id" json:"id"`
+    Text      string              `bson:"text" json:"text"`
+    Done      bool                `bson:"done" json:"done"`
+    DoneBy    *primitive.ObjectID `bson:"done_by,omitempty" json:"done_by,omitempty"`
+    DoneAt    *time.Time          `bson:"done_at,omitempty" json:"done_at,omitempty"`
+    CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+}
+
+// FillChecklistRatio sets ChecklistRatio to the share of checklist items
+// that are done, rounded to two decimals, or leaves it nil when the task
+// has no checklist
+func (t *Task) FillChecklistRatio() {
+    t.ChecklistRatio = nil
+    if len(t.Checklist) == 0 {
+        return
+    }
+    done := 0
+    for _, item := range t.Checklist {
+        if item.Done {
+            done++
+        }
+    }
+    ratio := math.Round(float64(done)/float64(len(t.Checklist))*100) / 100
+    t.ChecklistRatio = &ratio
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CommentMaxLength limits the size of a comment body
+const CommentMaxLength = 5000
+
+// Comment is a message on a task. Replies point at a top-level comment.
+type Comment struct {
+    ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+    TaskID    primitive.ObjectID   `bson:"task_id" json:"task_id"`
+    ParentID  *primitive.ObjectID  `bson:"parent_id,omitempty" json:"parent_id,omitempty"` // set on replies
+    AuthorID  primitive.ObjectID   `bson:"author_id" json:"author_id"`
+    Body      string               `bson:"body" json:"body"`
+    Mentions  []primitive.ObjectID `bson:"mentions,omitempty" json:"mentions,omitempty"`
+    History   []CommentRevision    `bson:"history,omitempty" json:"history,omitempty"`
+    CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+    EditedAt  *time.Time           `bson:"edited_at,omitempty" json:"edited_at,omitempty"`
+}
+
+// CommentRevision is a previous version of an edited comment
+type CommentRevision struct {
+    Body     string    `bson:"body" json:"body"`
+    EditedAt time.Time `bson:"edited_at" json:"edited_at"` // when this version was replaced
+}
+
+// CommentThread is a top-level comment with its replies
+type CommentThread struct {
+    Comment
+    Replies []Comment `json:"replies"`
+}
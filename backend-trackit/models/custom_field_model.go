@@ -0,0 +1,214 @@
+package models
+
+import (
+    "fmt"
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Custom field types
+const (
+    CustomFieldText        = "text"
+    CustomFieldNumber      = "number"
+    CustomFieldDate        = "date"
+    CustomFieldSelect      = "select"
+    CustomFieldMultiSelect = "multi_select"
+    CustomFieldUser        = "user"
+)
+
+var CustomFieldTypes = []string{
+    CustomFieldText, CustomFieldNumber, CustomFieldDate,
+    CustomFieldSelect, CustomFieldMultiSelect, CustomFieldUser,
+}
+
+// Limits applied to custom fields
+const (
+    MaxCustomFields            = 50
+    CustomFieldNameMaxLength   = 100
+    CustomFieldTextMaxLength   = 1000
+    MaxCustomFieldOptions      = 100
+    CustomFieldOptionMaxLength = 100
+)
+
+// customFieldKeyPattern keeps keys usable as a single bson path segment
+// and as a query parameter suffix
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// CustomField defines a field tasks may carry in their custom_fields map,
+// under Key. Definitions are shared across the workspace and apply to every
+// task, like the workflow; only their creator or an admin can change them.
+type CustomField struct {
+    ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    Key       string             `bson:"key" json:"key"`
+    Name      string             `bson:"name" json:"name"`
+    Type      string             `bson:"type" json:"type"`
+    Options   []string           `bson:"options,omitempty" json:"options,omitempty"` // select and multi_select only
+    CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+    CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+    UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// IsValidCustomFieldKey reports whether key may name a custom field
+func IsValidCustomFieldKey(key string) bool {
+    return customFieldKeyPattern.MatchString(key)
+}
+
+// Validate checks a definition, returning every problem found
+func (f CustomField) Validate() ValidationErrors {
+    var errs ValidationErrors
+
+    if !IsValidCustomFieldKey(f.Key) {
+        errs = append(errs, FieldError{Field: "key", Message: "must start with a lowercase letter and contain only lowercase letters, digits and underscores, up to 40 characters"})
+    }
+    if strings.TrimSpace(f.Name) == "" {
+        errs = append(errs, FieldError{Field: "name", Message: "cannot be empty"})
+    } else if utf8.RuneCountInString(f.Name) > CustomFieldNameMaxLength {
+        errs = append(errs, FieldError{Field: "name", Message: fmt.Sprintf("must be at most %d characters", CustomFieldNameMaxLength)})
+    }
+    if !contains(CustomFieldTypes, f.Type) {
+        errs = append(errs, FieldError{Field: "type", Message: "must be one of: " + strings.Join(CustomFieldTypes, ", ")})
+    }
+
+    if f.HasOptions() {
+        if len(f.Options) == 0 {
+            errs = append(errs, FieldError{Field: "options", Message: "must not be empty"})
+        }
+        if len(f.Options) > MaxCustomFieldOptions {
+            errs = append(errs, FieldError{Field: "options", Message: fmt.Sprintf("must contain at most %d options", MaxCustomFieldOptions)})
+        }
+        seen := make(map[string]bool)
+        for _, option := range f.Options {
+            switch {
+            case strings.TrimSpace(option) == "":
+                errs = append(errs, FieldError{Field: "options", Message: "cannot contain empty options"})
+            case utf8.RuneCountInString(option) > CustomFieldOptionMaxLength:
+                errs = append(errs, FieldError{Field: "options", Message: fmt.Sprintf("option %q is longer than %d characters", option, CustomFieldOptionMaxLength)})
+            case seen[option]:
+                errs = append(errs, FieldError{Field: "options", Message: fmt.Sprintf("duplicate option %q", option)})
+            }
+            seen[option] = true
+        }
+    } else if len(f.Options) > 0 {
+        errs = append(errs, FieldError{Field: "options", Message: "are only allowed on select and multi_select fields"})
+    }
+    return errs
+}
+
+// HasOptions reports whether values are drawn from Options
+func (f CustomField) HasOptions() bool {
+    return f.Type == CustomFieldSelect || f.Type == CustomFieldMultiSelect
+}
+
+// Sortable reports whether tasks can be sorted by the field
+func (f CustomField) Sortable() bool {
+    return f.Type != CustomFieldMultiSelect
+}
+
+// Ranged reports whether the field can be filtered with min and max bounds
+func (f CustomField) Ranged() bool {
+    return f.Type == CustomFieldNumber || f.Type == CustomFieldDate
+}
+
+// Convert validates a JSON value for the field and returns it as stored
+func (f CustomField) Convert(value interface{}) (interface{}, error) {
+    switch f.Type {
+    case CustomFieldText:
+        s, ok := value.(string)
+        if !ok {
+            return nil, fmt.Errorf("must be a string")
+        }
+        if utf8.RuneCountInString(s) > CustomFieldTextMaxLength {
+            return nil, fmt.Errorf("must be at most %d characters", CustomFieldTextMaxLength)
+        }
+        return s, nil
+    case CustomFieldNumber:
+        n, ok := value.(float64)
+        if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+            return nil, fmt.Errorf("must be a number")
+        }
+        return n, nil
+    case CustomFieldDate:
+        s, ok := value.(string)
+        if !ok {
+            return nil, fmt.Errorf("must be a date (YYYY-MM-DD) or an RFC3339 timestamp")
+        }
+        return f.ParseQueryValue(s)
+    case CustomFieldSelect:
+        s, ok := value.(string)
+        if !ok || !contains(f.Options, s) {
+            return nil, fmt.Errorf("must be one of: %s", strings.Join(f.Options, ", "))
+        }
+        return s, nil
+    case CustomFieldMultiSelect:
+        items, ok := value.([]interface{})
+        if !ok {
+            return nil, fmt.Errorf("must be an array of options")
+        }
+        selected := make([]string, 0, len(items))
+        for _, item := range items {
+            s, ok := item.(string)
+            if !ok || !contains(f.Options, s) {
+                return nil, fmt.Errorf("must only contain: %s", strings.Join(f.Options, ", "))
+            }
+            if !contains(selected, s) {
+                selected = append(selected, s)
+            }
+        }
+        return selected, nil
+    case CustomFieldUser:
+        return convertObjectID(value)
+    }
+    return nil, fmt.Errorf("has unknown type %q", f.Type)
+}
+
+// ParseQueryValue converts a query string value for the field to the form
+// it is stored in, so it can be compared against stored values
+func (f CustomField) ParseQueryValue(raw string) (interface{}, error) {
+    switch f.Type {
+    case CustomFieldNumber:
+        n, err := strconv.ParseFloat(raw, 64)
+        if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+            return nil, fmt.Errorf("must be a number")
+        }
+        return n, nil
+    case CustomFieldDate:
+        if t, err := time.Parse(time.RFC3339, raw); err == nil {
+            return t.UTC(), nil
+        }
+        t, err := time.Parse("2006-01-02", raw)
+        if err != nil {
+            return nil, fmt.Errorf("must be a date (YYYY-MM-DD) or an RFC3339 timestamp")
+        }
+        return t, nil
+    case CustomFieldUser:
+        return convertObjectID(raw)
+    case CustomFieldSelect, CustomFieldMultiSelect:
+        if !contains(f.Options, raw) {
+            return nil, fmt.Errorf("must be one of: %s", strings.Join(f.Options, ", "))
+        }
+        return raw, nil
+    }
+    return raw, nil
+}
+
+// convertCustomFields checks the shape of a custom_fields payload: an
+// object keyed by field key. The values are checked against the field
+// definitions by the handlers, which load them; null removes a value.
+func convertCustomFields(value interface{}) (interface{}, error) {
+    fields, ok := value.(map[string]interface{})
+    if !ok {
+        return nil, fmt.Errorf("must be an object keyed by custom field key")
+    }
+    for key := range fields {
+        if !IsValidCustomFieldKey(key) {
+            return nil, fmt.Errorf("%q is not a valid custom field key", key)
+        }
+    }
+    return fields, nil
+}
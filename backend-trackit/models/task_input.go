@@ -0,0 +1,367 @@
+package models
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Task statuses
+const (
+    StatusTodo       = "todo"
+    StatusInProgress = "in_progress"
+    StatusBlocked    = "blocked"
+    StatusDone       = "done"
+    StatusCancelled  = "cancelled"
+)
+
+// Task priorities
+const (
+    PriorityLow    = "low"
+    PriorityMedium = "medium"
+    PriorityHigh   = "high"
+    PriorityUrgent = "urgent"
+)
+
+// Limits applied to task input
+const (
+    TitleMaxLength       = 200
+    DescriptionMaxLength = 10000
+    MaxTags              = 20
+    TagMaxLength         = 32
+    MaxAssignees         = 20
+)
+
+var TaskStatuses = []string{StatusTodo, StatusInProgress, StatusBlocked, StatusDone, StatusCancelled}
+var TaskPriorities = []string{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+// legacyStatusAliases maps status values older clients still send
+var legacyStatusAliases = map[string]string{
+    "completed": StatusDone,
+}
+
+// FieldError describes why a single input field was rejected
+type FieldError struct {
+    Field   string `json:"field"`
+    Message string `json:"message"`
+}
+
+// ValidationErrors collects every invalid field of a payload
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+    parts := make([]string, len(v))
+    for i, e := range v {
+        parts[i] = e.Field + ": " + e.Message
+    }
+    return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// TaskInput is a validated create or update payload, keyed by JSON field
+// name. Values are already converted to the types stored on Task.
+type TaskInput struct {
+    // Set holds the fields to write
+    Set map[string]interface{}
+    // Unset lists optional fields that were explicitly sent as null
+    Unset []string
+}
+
+// readOnlyTaskFields are server-managed; clients often echo them back, so
+// they are dropped instead of rejected
+var readOnlyTaskFields = map[string]bool{
+    "id":         true,
+    "created_by": true,
+    "created_at": true,
+    "updated_at": true,
+
+    "last_transition": true,
+    "subtasks":        true,
+    "blocked_by":      true,
+    "deleted_at":      true,
+    "deleted_by":      true,
+    "deleted_with":    true,
+    "version":         true,
+    "recurrence":      true,
+    "time_spent":      true,
+    "checklist":       true,
+    "checklist_ratio": true,
+    "attachments":     true,
+    "rank":            true,
+    "completed_at":    true,
+    "archived_at":     true,
+    "archived_by":     true,
+    "unarchived_at":   true,
+}
+
+// taskFieldRule validates and converts one JSON field
+type taskFieldRule struct {
+    nullable bool
+    convert  func(value interface{}) (interface{}, error)
+}
+
+var taskFieldRules = map[string]taskFieldRule{
+    "title":       {convert: convertTitle},
+    "description": {convert: convertDescription},
+    "status":      {convert: convertStatus},
+    "priority":    {convert: enumConverter(TaskPriorities)},
+    "progress":    {convert: convertProgress},
+    "due_date":    {nullable: true, convert: convertDueDate},
+    "assigned_to": {nullable: true, convert: convertAssignees},
+    "tags":        {nullable: true, convert: convertTags},
+    "parent_id":   {nullable: true, convert: convertObjectID},
+
+    "time_estimate": {nullable: true, convert: convertTimeEstimate},
+    "custom_fields": {nullable: true, convert: convertCustomFields},
+}
+
+// ValidateTaskInput checks a decoded JSON payload against the task schema.
+// Partial inputs (updates) may omit any field; full inputs (creates) must
+// include a title. Every invalid field is reported, not just the first.
+func ValidateTaskInput(payload map[string]interface{}, partial bool) (*TaskInput, ValidationErrors) {
+    input := &TaskInput{Set: make(map[string]interface{})}
+    var errs ValidationErrors
+
+    for field, value := range payload {
+        if readOnlyTaskFields[field] {
+            continue
+        }
+        rule, ok := taskFieldRules[field]
+        if !ok {
+            errs = append(errs, FieldError{Field: field, Message: "unknown field"})
+            continue
+        }
+        if value == nil {
+            if !rule.nullable {
+                errs = append(errs, FieldError{Field: field, Message: "cannot be null"})
+                continue
+            }
+            input.Unset = append(input.Unset, field)
+            continue
+        }
+        converted, err := rule.convert(value)
+        if err != nil {
+            errs = append(errs, FieldError{Field: field, Message: err.Error()})
+            continue
+        }
+        input.Set[field] = converted
+    }
+
+    if !partial {
+        if _, ok := payload["title"]; !ok {
+            errs = append(errs, FieldError{Field: "title", Message: "is required"})
+        }
+    }
+
+    if len(errs) > 0 {
+        sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+        return nil, errs
+    }
+    sort.Strings(input.Unset)
+    return input, nil
+}
+
+// IsReadOnlyTaskField reports whether a JSON task field is server-managed
+func IsReadOnlyTaskField(field string) bool {
+    return readOnlyTaskFields[field]
+}
+
+// IsValidStatus reports whether s is one of TaskStatuses
+func IsValidStatus(s string) bool {
+    return contains(TaskStatuses, s)
+}
+
+// PriorityRank orders priorities from low to urgent, or is -1 for an
+// unknown priority
+func PriorityRank(priority string) int {
+    return indexOf(TaskPriorities, priority)
+}
+
+// StatusRank orders statuses along the task lifecycle, or is -1 for an
+// unknown status
+func StatusRank(status string) int {
+    return indexOf(TaskStatuses, status)
+}
+
+// NormalizeTags trims, lowercases and de-duplicates tags, joining inner
+// whitespace with dashes so "Front End" and "front-end" are the same tag
+func NormalizeTags(tags []string) []string {
+    normalized := make([]string, 0, len(tags))
+    seen := make(map[string]bool)
+    for _, tag := range tags {
+        tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+        if tag == "" || seen[tag] {
+            continue
+        }
+        seen[tag] = true
+        normalized = append(normalized, tag)
+    }
+    return normalized
+}
+
+// ------------------ Field Converters ------------------
+
+func convertTitle(value interface{}) (interface{}, error) {
+    s, ok := value.(string)
+    if !ok {
+        return nil, fmt.Errorf("must be a string")
+    }
+    s = strings.TrimSpace(s)
+    if s == "" {
+        return nil, fmt.Errorf("cannot be empty")
+    }
+    if utf8.RuneCountInString(s) > TitleMaxLength {
+        return nil, fmt.Errorf("must be at most %d characters", TitleMaxLength)
+    }
+    return s, nil
+}
+
+func convertDescription(value interface{}) (interface{}, error) {
+    s, ok := value.(string)
+    if !ok {
+        return nil, fmt.Errorf("must be a string")
+    }
+    if utf8.RuneCountInString(s) > DescriptionMaxLength {
+        return nil, fmt.Errorf("must be at most %d characters", DescriptionMaxLength)
+    }
+    return s, nil
+}
+
+func convertStatus(value interface{}) (interface{}, error) {
+    if s, ok := value.(string); ok {
+        if alias, ok := legacyStatusAliases[s]; ok {
+            return alias, nil
+        }
+    }
+    return enumConverter(TaskStatuses)(value)
+}
+
+func enumConverter(allowed []string) func(interface{}) (interface{}, error) {
+    return func(value interface{}) (interface{}, error) {
+        s, ok := value.(string)
+        if !ok || !contains(allowed, s) {
+            return nil, fmt.Errorf("must be one of: %s", strings.Join(allowed, ", "))
+        }
+        return s, nil
+    }
+}
+
+func convertProgress(value interface{}) (interface{}, error) {
+    n, ok := value.(float64)
+    if !ok || n != math.Trunc(n) || n < 0 || n > 100 {
+        return nil, fmt.Errorf("must be an integer between 0 and 100")
+    }
+    return int(n), nil
+}
+
+// convertTimeEstimate takes an estimate in whole seconds
+func convertTimeEstimate(value interface{}) (interface{}, error) {
+    n, ok := value.(float64)
+    limit := MaxTimeEstimate.Seconds()
+    if !ok || n != math.Trunc(n) || n < 0 || n > limit {
+        return nil, fmt.Errorf("must be a whole number of seconds between 0 and %d", int64(limit))
+    }
+    return int64(n), nil
+}
+
+func convertDueDate(value interface{}) (interface{}, error) {
+    s, ok := value.(string)
+    if !ok {
+        return nil, fmt.Errorf("must be an RFC3339 timestamp")
+    }
+    t, err := time.Parse(time.RFC3339, s)
+    if err != nil {
+        return nil, fmt.Errorf("must be an RFC3339 timestamp")
+    }
+    return t.UTC(), nil
+}
+
+func convertObjectID(value interface{}) (interface{}, error) {
+    s, ok := value.(string)
+    if !ok {
+        return nil, fmt.Errorf("must be an ID string")
+    }
+    id, err := primitive.ObjectIDFromHex(s)
+    if err != nil {
+        return nil, fmt.Errorf("must be a valid ID")
+    }
+    return id, nil
+}
+
+// convertAssignees accepts a single user ID, as older clients send, or an
+// array of them, and returns the de-duplicated IDs
+func convertAssignees(value interface{}) (interface{}, error) {
+    var raw []interface{}
+    switch v := value.(type) {
+    case string:
+        raw = []interface{}{v}
+    case []interface{}:
+        raw = v
+    default:
+        return nil, fmt.Errorf("must be a user ID or an array of user IDs")
+    }
+
+    ids := make([]primitive.ObjectID, 0, len(raw))
+    seen := make(map[primitive.ObjectID]bool)
+    for _, item := range raw {
+        s, ok := item.(string)
+        if !ok {
+            return nil, fmt.Errorf("must be a user ID or an array of user IDs")
+        }
+        id, err := primitive.ObjectIDFromHex(s)
+        if err != nil {
+            return nil, fmt.Errorf("%q is not a valid user ID", s)
+        }
+        if !seen[id] {
+            seen[id] = true
+            ids = append(ids, id)
+        }
+    }
+    if len(ids) > MaxAssignees {
+        return nil, fmt.Errorf("must contain at most %d assignees", MaxAssignees)
+    }
+    return ids, nil
+}
+
+func convertTags(value interface{}) (interface{}, error) {
+    items, ok := value.([]interface{})
+    if !ok {
+        return nil, fmt.Errorf("must be an array of strings")
+    }
+    tags := make([]string, 0, len(items))
+    for _, item := range items {
+        s, ok := item.(string)
+        if !ok {
+            return nil, fmt.Errorf("must be an array of strings")
+        }
+        tags = append(tags, s)
+    }
+
+    tags = NormalizeTags(tags)
+    if len(tags) > MaxTags {
+        return nil, fmt.Errorf("must contain at most %d tags", MaxTags)
+    }
+    for _, tag := range tags {
+        if utf8.RuneCountInString(tag) > TagMaxLength {
+            return nil, fmt.Errorf("tag %q is longer than %d characters", tag, TagMaxLength)
+        }
+    }
+    return tags, nil
+}
+
+func contains(values []string, s string) bool {
+    return indexOf(values, s) >= 0
+}
+
+func indexOf(values []string, s string) int {
+    for i, v := range values {
+        if v == s {
+            return i
+        }
+    }
+    return -1
+}
@@ -13,17 +13,87 @@ type User struct {
 }
 
 type Task struct {
-    ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-    Title       string             `bson:"title" json:"title"`
-    Description string             `bson:"description" json:"description"`
-    Status      string             `bson:"status" json:"status"`
-    Priority    string             `bson:"priority" json:"priority"`
-    DueDate     *time.Time         `bson:"due_date,omitempty" json:"due_date,omitempty"`
-    AssignedTo  primitive.ObjectID `bson:"assigned_to,omitempty" json:"assigned_to,omitempty"`
-    CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
-    CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-    UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
-    Tags        []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+    ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+    Title       string               `bson:"title" json:"title"`
+    Description string               `bson:"description" json:"description"`
+    Status      string               `bson:"status" json:"status"`
+    Priority    string               `bson:"priority" json:"priority"`
+    Progress    int                  `bson:"progress" json:"progress"` // percent complete, 0-100
+    DueDate     *time.Time           `bson:"due_date,omitempty" json:"due_date,omitempty"`
+    AssignedTo  []primitive.ObjectID `bson:"assigned_to,omitempty" json:"assigned_to,omitempty"`
+    CreatedBy   primitive.ObjectID   `bson:"created_by" json:"created_by"`
+    CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+    UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
+    Tags        []string             `bson:"tags,omitempty" json:"tags,omitempty"`
+    ParentID    *primitive.ObjectID  `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+    Subtasks    *SubtaskRollup       `bson:"subtasks,omitempty" json:"subtasks,omitempty"`
+    BlockedBy   []primitive.ObjectID `bson:"blocked_by,omitempty" json:"blocked_by,omitempty"`
+
+    // Rank orders the task within its status column on the board; see
+    // services.RankBetween
+    Rank string `bson:"rank,omitempty" json:"rank,omitempty"`
+
+    // Sort keys kept in step with Priority and Status, so tasks sort in
+    // the order of those enums rather than alphabetically
+    PriorityRank int `bson:"priority_rank" json:"-"`
+    StatusRank   int `bson:"status_rank" json:"-"`
+
+    // Effort in seconds: the estimate is set by users, the time spent sums
+    // the task's finished time entries
+    TimeEstimate *int64 `bson:"time_estimate,omitempty" json:"time_estimate,omitempty"`
+    TimeSpent    int64  `bson:"time_spent,omitempty" json:"time_spent"`
+
+    // Checklist items in display order. ChecklistRatio is computed when
+    // tasks are listed and is not stored.
+    Checklist      []ChecklistItem `bson:"checklist,omitempty" json:"checklist,omitempty"`
+    ChecklistRatio *float64        `bson:"-" json:"checklist_ratio,omitempty"`
+
+    Attachments []Attachment `bson:"attachments,omitempty" json:"attachments,omitempty"`
+
+    // Values of the custom fields defined in the custom_fields collection,
+    // keyed by field key
+    CustomFields map[string]interface{} `bson:"custom_fields,omitempty" json:"custom_fields,omitempty"`
+
+    LastTransition *StatusTransition `bson:"last_transition,omitempty" json:"last_transition,omitempty"`
+
+    // CompletedAt is when the task last moved to done, unset while it is
+    // in any other status
+    CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+
+    // Version goes up by one on every edit and is served as the task's ETag
+    Version int64 `bson:"version" json:"version"`
+
+    // Set while the task is in the trash. DeletedWith names the task whose
+    // deletion took this one along, so restoring that task restores it too.
+    DeletedAt   *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+    DeletedBy   *primitive.ObjectID `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+    DeletedWith *primitive.ObjectID `bson:"deleted_with,omitempty" json:"deleted_with,omitempty"`
+
+    // Set while the task is archived. Archived tasks keep their comments and
+    // history but are left out of task listings. ArchivedBy is not set when
+    // the auto-archive policy archived the task. UnarchivedAt is when the
+    // task was last unarchived; the policy waits as long after it as after
+    // completion.
+    ArchivedAt   *time.Time          `bson:"archived_at,omitempty" json:"archived_at,omitempty"`
+    ArchivedBy   *primitive.ObjectID `bson:"archived_by,omitempty" json:"archived_by,omitempty"`
+    UnarchivedAt *time.Time          `bson:"unarchived_at,omitempty" json:"unarchived_at,omitempty"`
+
+    // Set on tasks a recurring task created
+    Recurrence *TaskRecurrence `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+}
+
+// TaskRecurrence links a generated task to the recurring task and the
+// occurrence it was created for. Each occurrence creates at most one task.
+type TaskRecurrence struct {
+    ID         primitive.ObjectID `bson:"id" json:"id"`
+    Occurrence time.Time          `bson:"occurrence" json:"occurrence"`
+}
+
+// SubtaskRollup summarizes the completion of a task's subtasks
+type SubtaskRollup struct {
+    Total    int `bson:"total" json:"total"`
+    Done     int `bson:"done" json:"done"`
+    Progress int `bson:"progress" json:"progress"` // percent complete, 0-100
 }
 
 type AITaskSuggestion struct {
@@ -32,21 +102,60 @@ type AITaskSuggestion struct {
     GeneratedAt time.Time          `bson:"generated_at" json:"generated_at"`
 }
 
+// TaskTemplate is a reusable blueprint for tasks. Title and Description may
+// contain {{variables}} that are filled in when a task is created from it.
 type TaskTemplate struct {
-    ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-    Name        string             `bson:"name" json:"name"`
-    Description string             `bson:"description" json:"description"`
-    Priority    string             `bson:"priority" json:"priority"`
-    Tags        []string           `bson:"tags" json:"tags"`
-    CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
-    CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+    ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+    Name        string               `bson:"name" json:"name"`
+    Title       string               `bson:"title" json:"title"`
+    Description string               `bson:"description" json:"description"`
+    Priority    string               `bson:"priority" json:"priority"`
+    Tags        []string             `bson:"tags" json:"tags"`
+    AssignedTo  []primitive.ObjectID `bson:"assigned_to,omitempty" json:"assigned_to,omitempty"`
+    CreatedBy   primitive.ObjectID   `bson:"created_by" json:"created_by"`
+    CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+    UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
 }
 
+// Catch-up policies decide what a recurring task does with occurrences that
+// were missed while the scheduler was not running
+const (
+    CatchUpAll    = "all"    // create a task for every missed occurrence
+    CatchUpLatest = "latest" // create one task for the most recent occurrence
+    CatchUpSkip   = "skip"   // only create tasks for occurrences that are on time
+)
+
+// CatchUpPolicies lists the valid catch-up policies
+var CatchUpPolicies = []string{CatchUpAll, CatchUpLatest, CatchUpSkip}
+
+// RecurringTask creates a task from TaskTemplate, on behalf of CreatedBy,
+// each time NextDue passes
 type RecurringTask struct {
     ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
     TaskTemplate primitive.ObjectID `bson:"task_template" json:"task_template"`
-    Frequency    string             `bson:"frequency" json:"frequency"` // daily, weekly, monthly
+    Frequency    string             `bson:"frequency,omitempty" json:"frequency,omitempty"` // daily, weekly, monthly; used when Rule is empty
+    Rule         string             `bson:"rule,omitempty" json:"rule,omitempty"`           // RFC 5545 RRULE, e.g. FREQ=MONTHLY;BYDAY=TU;BYSETPOS=2
+    Timezone     string             `bson:"timezone,omitempty" json:"timezone,omitempty"`   // IANA zone the rule is evaluated in, UTC by default
+    ExDates      []time.Time        `bson:"exdates,omitempty" json:"exdates,omitempty"`     // occurrences to leave out
+    StartsAt     time.Time          `bson:"starts_at,omitempty" json:"starts_at,omitempty"` // first occurrence
     NextDue      time.Time          `bson:"next_due" json:"next_due"`
     LastCreated  time.Time          `bson:"last_created" json:"last_created"`
     Active       bool               `bson:"active" json:"active"`
+    CatchUp      string             `bson:"catch_up,omitempty" json:"catch_up,omitempty"` // defaults to CatchUpLatest
+    CreatedBy    primitive.ObjectID `bson:"created_by" json:"created_by"`
+    CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+    UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+
+    // Why the scheduler deactivated the recurring task, if it did
+    LastError string `bson:"last_error,omitempty" json:"last_error,omitempty"`
+}
+
+// IsAssignedTo reports whether the user is one of the task's assignees
+func (t Task) IsAssignedTo(userID primitive.ObjectID) bool {
+    for _, id := range t.AssignedTo {
+        if id == userID {
+            return true
+        }
+    }
+    return false
 }
@@ -0,0 +1,36 @@
+package models
+
+import (
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limits applied to time entries
+const (
+    TimeEntryNoteMaxLength = 1000
+    MaxTimeEntryDuration   = 24 * time.Hour
+    MaxTimeEstimate        = 10000 * time.Hour
+)
+
+// Time entry sources
+const (
+    TimeEntryTimer  = "timer"
+    TimeEntryManual = "manual"
+)
+
+// TimeEntry is time a user spent on a task, recorded with a timer or by
+// hand. A running timer has no EndedAt yet; a user runs one at a time.
+type TimeEntry struct {
+    ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    TaskID    primitive.ObjectID `bson:"task_id" json:"task_id"`
+    UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+    Source    string             `bson:"source" json:"source"`
+    Running   bool               `bson:"running" json:"running"`
+    StartedAt time.Time          `bson:"started_at" json:"started_at"`
+    EndedAt   *time.Time         `bson:"ended_at,omitempty" json:"ended_at,omitempty"`
+    Duration  int64              `bson:"duration" json:"duration"` // seconds, 0 while running
+    Note      string             `bson:"note,omitempty" json:"note,omitempty"`
+    CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+    UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limits applied to saved views
+const (
+    ViewNameMaxLength = 100
+    MaxViewColumns    = 30
+    MaxViewsPerUser   = 100
+)
+
+// SavedView is a named task list: the GET /api/tasks filters and sort it
+// runs, and the columns the client shows. Shared views are visible to every
+// user but only their creator can change them. They always run with the
+// permissions of the user viewing them, so "assignee=me" means the viewer.
+type SavedView struct {
+    ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    Name      string             `bson:"name" json:"name"`
+    Filters   map[string]string  `bson:"filters" json:"filters"` // GET /api/tasks query parameters
+    Sort      string             `bson:"sort,omitempty" json:"sort,omitempty"`
+    Columns   []string           `bson:"columns,omitempty" json:"columns,omitempty"`
+    Shared    bool               `bson:"shared" json:"shared"`
+    CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+    CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+    UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// SmartView is a built-in view whose filters are worked out when it runs,
+// such as tasks due this week
+type SmartView struct {
+    ID      string   `json:"id"`
+    Name    string   `json:"name"`
+    Sort    string   `json:"sort"`
+    Columns []string `json:"columns,omitempty"`
+    Builtin bool     `json:"builtin"`
+}
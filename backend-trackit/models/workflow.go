@@ -0,0 +1,141 @@
+package models
+
+import (
+    "fmt"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultWorkflowName is the key of the workflow every task follows
+const DefaultWorkflowName = "default"
+
+// WorkflowTransition allows tasks to move from one status to another
+type WorkflowTransition struct {
+    From             string `bson:"from" json:"from"`
+    To               string `bson:"to" json:"to"`
+    RequiresComment  bool   `bson:"requires_comment" json:"requires_comment"`
+    RequiresAssignee bool   `bson:"requires_assignee" json:"requires_assignee"`
+}
+
+// Workflow defines the status lifecycle of tasks. States are drawn from
+// TaskStatuses; a workflow may use a subset of them.
+type Workflow struct {
+    ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+    Name         string               `bson:"name" json:"name"`
+    InitialState string               `bson:"initial_state" json:"initial_state"`
+    States       []string             `bson:"states" json:"states"`
+    Transitions  []WorkflowTransition `bson:"transitions" json:"transitions"`
+    UpdatedBy    primitive.ObjectID   `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+    UpdatedAt    time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// StatusTransition records the most recent status change of a task
+type StatusTransition struct {
+    From    string             `bson:"from" json:"from"`
+    To      string             `bson:"to" json:"to"`
+    Comment string             `bson:"comment,omitempty" json:"comment,omitempty"`
+    By      primitive.ObjectID `bson:"by" json:"by"`
+    At      time.Time          `bson:"at" json:"at"`
+}
+
+// DefaultWorkflow is used until a team stores its own
+func DefaultWorkflow() Workflow {
+    return Workflow{
+        Name:         DefaultWorkflowName,
+        InitialState: StatusTodo,
+        States:       append([]string(nil), TaskStatuses...),
+        Transitions: []WorkflowTransition{
+            {From: StatusTodo, To: StatusInProgress},
+            {From: StatusTodo, To: StatusBlocked},
+            {From: StatusTodo, To: StatusDone},
+            {From: StatusTodo, To: StatusCancelled},
+            {From: StatusInProgress, To: StatusTodo},
+            {From: StatusInProgress, To: StatusBlocked},
+            {From: StatusInProgress, To: StatusDone},
+            {From: StatusInProgress, To: StatusCancelled},
+            {From: StatusBlocked, To: StatusTodo},
+            {From: StatusBlocked, To: StatusInProgress},
+            {From: StatusBlocked, To: StatusCancelled},
+            {From: StatusDone, To: StatusInProgress, RequiresComment: true},
+            {From: StatusCancelled, To: StatusTodo, RequiresComment: true},
+        },
+    }
+}
+
+// HasState reports whether the workflow uses the status
+func (w Workflow) HasState(status string) bool {
+    return contains(w.States, status)
+}
+
+// FindTransition returns the transition between two states, if allowed
+func (w Workflow) FindTransition(from, to string) (WorkflowTransition, bool) {
+    for _, t := range w.Transitions {
+        if t.From == from && t.To == to {
+            return t, true
+        }
+    }
+    return WorkflowTransition{}, false
+}
+
+// NextStates lists the states reachable from the given status. A status
+// outside the workflow (data written before it existed) may move to any state.
+func (w Workflow) NextStates(from string) []string {
+    if !w.HasState(from) {
+        next := make([]string, 0, len(w.States))
+        for _, s := range w.States {
+            if s != from {
+                next = append(next, s)
+            }
+        }
+        return next
+    }
+
+    next := []string{}
+    for _, t := range w.Transitions {
+        if t.From == from {
+            next = append(next, t.To)
+        }
+    }
+    return next
+}
+
+// Validate checks that the workflow is internally consistent
+func (w Workflow) Validate() ValidationErrors {
+    var errs ValidationErrors
+
+    if len(w.States) == 0 {
+        errs = append(errs, FieldError{Field: "states", Message: "must not be empty"})
+    }
+    seen := make(map[string]bool)
+    for _, s := range w.States {
+        if !IsValidStatus(s) {
+            errs = append(errs, FieldError{Field: "states", Message: fmt.Sprintf("unknown status %q", s)})
+        }
+        if seen[s] {
+            errs = append(errs, FieldError{Field: "states", Message: fmt.Sprintf("duplicate state %q", s)})
+        }
+        seen[s] = true
+    }
+
+    if !seen[w.InitialState] {
+        errs = append(errs, FieldError{Field: "initial_state", Message: "must be one of the workflow states"})
+    }
+
+    pairs := make(map[[2]string]bool)
+    for i, t := range w.Transitions {
+        field := fmt.Sprintf("transitions[%d]", i)
+        if !seen[t.From] || !seen[t.To] {
+            errs = append(errs, FieldError{Field: field, Message: "from and to must be workflow states"})
+        }
+        if t.From == t.To {
+            errs = append(errs, FieldError{Field: field, Message: "from and to must differ"})
+        }
+        if pairs[[2]string{t.From, t.To}] {
+            errs = append(errs, FieldError{Field: field, Message: "duplicate transition"})
+        }
+        pairs[[2]string{t.From, t.To}] = true
+    }
+
+    return errs
+}
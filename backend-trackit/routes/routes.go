@@ -12,6 +12,7 @@ func RegisterRoutes(router *gin.Engine) {
 	{
 		api.POST("/register", handlers.Register)
 		api.POST("/login", handlers.Login)
+		api.GET("/ws", handlers.HandleWebSocket) // Authenticates with the token query parameter
 
 		// Protected routes
 		protected := api.Group("/")
@@ -20,9 +21,77 @@ func RegisterRoutes(router *gin.Engine) {
 			protected.POST("/logout", handlers.Logout) // Move logout inside protected routes
 			protected.GET("/me", handlers.GetMe)
 			protected.GET("/tasks", handlers.GetTasks)
+			protected.GET("/tasks/search", handlers.SearchTasks)
+			protected.GET("/tasks/trash", handlers.GetTrash)
+			protected.GET("/tasks/archive", handlers.GetArchive)
+			protected.POST("/tasks/bulk", handlers.BulkTasks)
 			protected.POST("/tasks", handlers.CreateTask)
+			protected.GET("/tasks/:id", handlers.GetTask)
 			protected.PUT("/tasks/:id", handlers.UpdateTask)
+			protected.PATCH("/tasks/:id", handlers.PatchTask)
 			protected.DELETE("/tasks/:id", handlers.DeleteTask)
+			protected.POST("/tasks/:id/restore", handlers.RestoreTask)
+			protected.POST("/tasks/:id/archive", handlers.ArchiveTask)
+			protected.POST("/tasks/:id/unarchive", handlers.UnarchiveTask)
+			protected.POST("/tasks/:id/transition", handlers.TransitionTask)
+			protected.POST("/tasks/:id/move", handlers.MoveTask)
+			protected.POST("/tasks/:id/assign", handlers.AssignTask)
+			protected.DELETE("/tasks/:id/assign", handlers.UnassignTask)
+			protected.GET("/tasks/:id/subtasks", handlers.GetSubtasks)
+			protected.PUT("/tasks/:id/parent", handlers.SetTaskParent)
+			protected.GET("/tasks/:id/dependencies", handlers.GetDependencies)
+			protected.POST("/tasks/:id/dependencies", handlers.AddDependency)
+			protected.DELETE("/tasks/:id/dependencies/:blockerId", handlers.RemoveDependency)
+			protected.GET("/tasks/:id/comments", handlers.GetComments)
+			protected.POST("/tasks/:id/comments", handlers.CreateComment)
+			protected.PUT("/tasks/:id/comments/:commentId", handlers.UpdateComment)
+			protected.DELETE("/tasks/:id/comments/:commentId", handlers.DeleteComment)
+			protected.GET("/tasks/:id/activity", handlers.GetTaskActivity)
+			protected.POST("/tasks/:id/checklist", handlers.AddChecklistItem)
+			protected.PUT("/tasks/:id/checklist/order", handlers.ReorderChecklist)
+			protected.PUT("/tasks/:id/checklist/:itemId", handlers.UpdateChecklistItem)
+			protected.POST("/tasks/:id/checklist/:itemId/toggle", handlers.ToggleChecklistItem)
+			protected.DELETE("/tasks/:id/checklist/:itemId", handlers.DeleteChecklistItem)
+			protected.POST("/tasks/:id/attachments", handlers.UploadAttachment)
+			protected.GET("/tasks/:id/attachments", handlers.GetTaskAttachments)
+			protected.GET("/tasks/:id/attachments/:attachmentId", handlers.DownloadAttachment)
+			protected.DELETE("/tasks/:id/attachments/:attachmentId", handlers.DeleteAttachment)
+			protected.POST("/tasks/:id/timer/start", handlers.StartTimer)
+			protected.POST("/tasks/:id/timer/stop", handlers.StopTimer)
+			protected.GET("/tasks/:id/time-entries", handlers.GetTaskTimeEntries)
+			protected.POST("/tasks/:id/time-entries", handlers.CreateTimeEntry)
+			protected.PUT("/tasks/:id/time-entries/:entryId", handlers.UpdateTimeEntry)
+			protected.DELETE("/tasks/:id/time-entries/:entryId", handlers.DeleteTimeEntry)
+			protected.GET("/timer", handlers.GetRunningTimer)
+			protected.GET("/time-entries", handlers.GetTimeEntries)
+			protected.GET("/templates", handlers.GetTemplates)
+			protected.POST("/templates", handlers.CreateTemplate)
+			protected.GET("/templates/:id", handlers.GetTemplate)
+			protected.PUT("/templates/:id", handlers.UpdateTemplate)
+			protected.DELETE("/templates/:id", handlers.DeleteTemplate)
+			protected.POST("/templates/:id/instantiate", handlers.InstantiateTemplate)
+			protected.GET("/recurring", handlers.GetRecurringTasks)
+			protected.POST("/recurring", handlers.CreateRecurringTask)
+			protected.POST("/recurring/preview", handlers.PreviewRecurrence)
+			protected.GET("/recurring/:id", handlers.GetRecurringTask)
+			protected.PUT("/recurring/:id", handlers.UpdateRecurringTask)
+			protected.DELETE("/recurring/:id", handlers.DeleteRecurringTask)
+			protected.POST("/recurring/:id/pause", handlers.PauseRecurringTask)
+			protected.POST("/recurring/:id/resume", handlers.ResumeRecurringTask)
+			protected.POST("/recurring/:id/run-now", handlers.RunRecurringTaskNow)
+			protected.GET("/recurring/:id/tasks", handlers.GetRecurringTaskInstances)
+			protected.GET("/workflow", handlers.GetWorkflow)
+			protected.PUT("/workflow", middleware.AdminMiddleware(), handlers.UpdateWorkflow)
+			protected.GET("/custom-fields", handlers.GetCustomFields)
+			protected.POST("/custom-fields", handlers.CreateCustomField)
+			protected.PUT("/custom-fields/:id", handlers.UpdateCustomField)
+			protected.DELETE("/custom-fields/:id", handlers.DeleteCustomField)
+			protected.GET("/views", handlers.GetViews)
+			protected.POST("/views", handlers.CreateView)
+			protected.GET("/views/:id", handlers.GetView)
+			protected.PUT("/views/:id", handlers.UpdateView)
+			protected.DELETE("/views/:id", handlers.DeleteView)
+			protected.GET("/views/:id/tasks", handlers.GetViewTasks)
 			protected.POST("/ai/suggestions", handlers.GetAISuggestions)
 		}
 	}
@@ -0,0 +1,279 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "regexp"
+
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/gridfs"
+    "go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrFileNotFound is returned when a stored file does not exist
+var ErrFileNotFound = errors.New("file not found")
+
+// storageKeyPattern keeps keys to names that are safe as file names
+var storageKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// FileStorage stores the contents of uploaded files under a key chosen by
+// the caller
+type FileStorage interface {
+    // Save stores the contents of r under key and returns the number of
+    // bytes written. A failed save leaves nothing behind.
+    Save(ctx context.Context, key string, r io.Reader) (int64, error)
+    // Open returns the file stored under key, or ErrFileNotFound
+    Open(ctx context.Context, key string) (StoredFile, error)
+    // Delete removes the file stored under key. Deleting a file that does
+    // not exist is not an error.
+    Delete(ctx context.Context, key string) error
+}
+
+// StoredFile is an open stored file. It can seek, so downloads can serve
+// byte ranges.
+type StoredFile interface {
+    io.ReadSeekCloser
+    Size() int64
+}
+
+func checkStorageKey(key string) error {
+    if !storageKeyPattern.MatchString(key) {
+        return fmt.Errorf("invalid storage key %q", key)
+    }
+    return nil
+}
+
+// ------------------ Local Filesystem ------------------
+
+// LocalStorage keeps files in a directory on the local filesystem
+type LocalStorage struct {
+    dir string
+}
+
+// NewLocalStorage stores files in dir, creating it if needed
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+    if err := os.MkdirAll(dir, 0o750); err != nil {
+        return nil, err
+    }
+    return &LocalStorage{dir: dir}, nil
+}
+
+func (s *LocalStorage) Save(ctx context.Context, key string, r io.Reader) (int64, error) {
+    if err := checkStorageKey(key); err != nil {
+        return 0, err
+    }
+
+    // Write to a temporary file and rename it into place, so a file is
+    // never seen half written
+    tmp, err := os.CreateTemp(s.dir, ".upload-*")
+    if err != nil {
+        return 0, err
+    }
+    written, err := io.Copy(tmp, contextReader{ctx: ctx, r: r})
+    if closeErr := tmp.Close(); err == nil {
+        err = closeErr
+    }
+    if err == nil {
+        err = os.Rename(tmp.Name(), s.path(key))
+    }
+    if err != nil {
+        os.Remove(tmp.Name())
+        return 0, err
+    }
+    return written, nil
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (StoredFile, error) {
+    if err := checkStorageKey(key); err != nil {
+        return nil, err
+    }
+    file, err := os.Open(s.path(key))
+    if errors.Is(err, os.ErrNotExist) {
+        return nil, ErrFileNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    info, err := file.Stat()
+    if err != nil {
+        file.Close()
+        return nil, err
+    }
+    return localFile{File: file, size: info.Size()}, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+    if err := checkStorageKey(key); err != nil {
+        return err
+    }
+    err := os.Remove(s.path(key))
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    return err
+}
+
+func (s *LocalStorage) path(key string) string {
+    return filepath.Join(s.dir, key)
+}
+
+type localFile struct {
+    *os.File
+    size int64
+}
+
+func (f localFile) Size() int64 {
+    return f.size
+}
+
+// contextReader stops a copy once its context is done
+type contextReader struct {
+    ctx context.Context
+    r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+    if err := r.ctx.Err(); err != nil {
+        return 0, err
+    }
+    return r.r.Read(p)
+}
+
+// ------------------ GridFS ------------------
+
+// GridFSStorage keeps files in a MongoDB GridFS bucket, using the key as
+// the file's ID
+type GridFSStorage struct {
+    bucket *gridfs.Bucket
+}
+
+// NewGridFSStorage stores files in the named GridFS bucket of db
+func NewGridFSStorage(db *mongo.Database, bucketName string) (*GridFSStorage, error) {
+    bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+    if err != nil {
+        return nil, err
+    }
+    return &GridFSStorage{bucket: bucket}, nil
+}
+
+func (s *GridFSStorage) Save(ctx context.Context, key string, r io.Reader) (int64, error) {
+    if err := checkStorageKey(key); err != nil {
+        return 0, err
+    }
+    stream, err := s.bucket.OpenUploadStreamWithID(key, key)
+    if err != nil {
+        return 0, err
+    }
+    written, err := io.Copy(stream, contextReader{ctx: ctx, r: r})
+    if err != nil {
+        stream.Abort()
+        return 0, err
+    }
+    if err := stream.Close(); err != nil {
+        return 0, err
+    }
+    return written, nil
+}
+
+func (s *GridFSStorage) Open(ctx context.Context, key string) (StoredFile, error) {
+    if err := checkStorageKey(key); err != nil {
+        return nil, err
+    }
+    stream, err := s.bucket.OpenDownloadStream(key)
+    if errors.Is(err, gridfs.ErrFileNotFound) {
+        return nil, ErrFileNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &gridFSFile{bucket: s.bucket, key: key, stream: stream, size: stream.GetFile().Length}, nil
+}
+
+func (s *GridFSStorage) Delete(ctx context.Context, key string) error {
+    if err := checkStorageKey(key); err != nil {
+        return err
+    }
+    err := s.bucket.DeleteContext(ctx, key)
+    if errors.Is(err, gridfs.ErrFileNotFound) {
+        return nil
+    }
+    return err
+}
+
+// gridFSFile adds seeking to a GridFS download stream, which can only read
+// forward. Seeking backwards reopens the stream and skips to the offset.
+type gridFSFile struct {
+    bucket   *gridfs.Bucket
+    key      string
+    stream   *gridfs.DownloadStream
+    position int64 // where the next Read starts
+    streamAt int64 // where stream is positioned
+    size     int64
+}
+
+func (f *gridFSFile) Size() int64 {
+    return f.size
+}
+
+func (f *gridFSFile) Read(p []byte) (int, error) {
+    if f.position >= f.size {
+        return 0, io.EOF
+    }
+    if f.stream == nil || f.streamAt > f.position {
+        if err := f.reopen(); err != nil {
+            return 0, err
+        }
+    }
+    if f.streamAt < f.position {
+        skipped, err := f.stream.Skip(f.position - f.streamAt)
+        f.streamAt += skipped
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    n, err := f.stream.Read(p)
+    f.position += int64(n)
+    f.streamAt += int64(n)
+    return n, err
+}
+
+func (f *gridFSFile) Seek(offset int64, whence int) (int64, error) {
+    switch whence {
+    case io.SeekStart:
+    case io.SeekCurrent:
+        offset += f.position
+    case io.SeekEnd:
+        offset += f.size
+    default:
+        return 0, fmt.Errorf("invalid whence %d", whence)
+    }
+    if offset < 0 {
+        return 0, fmt.Errorf("negative position %d", offset)
+    }
+    f.position = offset
+    return offset, nil
+}
+
+func (f *gridFSFile) Close() error {
+    if f.stream == nil {
+        return nil
+    }
+    err := f.stream.Close()
+    f.stream = nil
+    return err
+}
+
+func (f *gridFSFile) reopen() error {
+    f.Close()
+    stream, err := f.bucket.OpenDownloadStream(f.key)
+    if err != nil {
+        return err
+    }
+    f.stream, f.streamAt = stream, 0
+    return nil
+}
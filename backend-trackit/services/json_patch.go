@@ -0,0 +1,337 @@
+package services
+
+import (
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "strconv"
+    "strings"
+)
+
+// PatchOperation is one operation of a JSON Patch document (RFC 6902)
+type PatchOperation struct {
+    Op    string          `json:"op"`
+    Path  string          `json:"path"`
+    From  string          `json:"from,omitempty"`
+    Value json.RawMessage `json:"value,omitempty"`
+}
+
+// PatchError reports the operation a JSON Patch failed on
+type PatchError struct {
+    Index      int
+    Op         string
+    Reason     string
+    TestFailed bool // a "test" operation did not match
+}
+
+func (e *PatchError) Error() string {
+    return fmt.Sprintf("operation %d (%s): %s", e.Index, e.Op, e.Reason)
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) to a decoded JSON
+// document: null removes a member, objects merge recursively and any other
+// value, arrays included, replaces the target outright
+func ApplyMergePatch(target, patch interface{}) interface{} {
+    patchObject, ok := patch.(map[string]interface{})
+    if !ok {
+        return patch
+    }
+    targetObject, ok := target.(map[string]interface{})
+    if !ok {
+        targetObject = make(map[string]interface{})
+    }
+
+    for key, value := range patchObject {
+        if value == nil {
+            delete(targetObject, key)
+            continue
+        }
+        targetObject[key] = ApplyMergePatch(targetObject[key], value)
+    }
+    return targetObject
+}
+
+// ApplyJSONPatch applies JSON Patch operations in order to a decoded JSON
+// document. The document may be modified in place; the patched document is
+// returned. If any operation fails the whole patch fails.
+func ApplyJSONPatch(doc interface{}, ops []PatchOperation) (interface{}, error) {
+    for i, op := range ops {
+        var err error
+        doc, err = applyPatchOperation(doc, op)
+        if err != nil {
+            if patchErr, ok := err.(*PatchError); ok {
+                patchErr.Index, patchErr.Op = i, op.Op
+                return nil, patchErr
+            }
+            return nil, &PatchError{Index: i, Op: op.Op, Reason: err.Error()}
+        }
+    }
+    return doc, nil
+}
+
+func applyPatchOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+    path, err := parseJSONPointer(op.Path)
+    if err != nil {
+        return nil, err
+    }
+
+    switch op.Op {
+    case "add", "replace", "test":
+        value, err := operationValue(op)
+        if err != nil {
+            return nil, err
+        }
+        switch op.Op {
+        case "add":
+            return addAt(doc, path, value)
+        case "replace":
+            return replaceAt(doc, path, value)
+        }
+        current, err := getAt(doc, path)
+        if err != nil {
+            return nil, err
+        }
+        if !reflect.DeepEqual(current, value) {
+            return nil, &PatchError{Reason: fmt.Sprintf("value at %q does not match", op.Path), TestFailed: true}
+        }
+        return doc, nil
+
+    case "remove":
+        doc, _, err := removeAt(doc, path)
+        return doc, err
+
+    case "move", "copy":
+        from, err := parseJSONPointer(op.From)
+        if err != nil {
+            return nil, err
+        }
+        if op.Op == "copy" {
+            value, err := getAt(doc, from)
+            if err != nil {
+                return nil, err
+            }
+            return addAt(doc, path, deepCopyJSON(value))
+        }
+        if isPointerPrefix(from, path) && len(from) < len(path) {
+            return nil, fmt.Errorf("cannot move %q into one of its children", op.From)
+        }
+        doc, value, err := removeAt(doc, from)
+        if err != nil {
+            return nil, err
+        }
+        return addAt(doc, path, value)
+    }
+    return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// operationValue decodes the value member, which must be present
+func operationValue(op PatchOperation) (interface{}, error) {
+    if len(op.Value) == 0 {
+        return nil, fmt.Errorf("value is required")
+    }
+    var value interface{}
+    if err := json.Unmarshal(op.Value, &value); err != nil {
+        return nil, fmt.Errorf("invalid value: %v", err)
+    }
+    return value, nil
+}
+
+// parseJSONPointer splits an RFC 6901 pointer into unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+    if pointer == "" {
+        return nil, nil
+    }
+    if !strings.HasPrefix(pointer, "/") {
+        return nil, fmt.Errorf("invalid path %q: must start with /", pointer)
+    }
+    tokens := strings.Split(pointer[1:], "/")
+    for i, token := range tokens {
+        tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+    }
+    return tokens, nil
+}
+
+func isPointerPrefix(prefix, path []string) bool {
+    if len(prefix) > len(path) {
+        return false
+    }
+    for i := range prefix {
+        if prefix[i] != path[i] {
+            return false
+        }
+    }
+    return true
+}
+
+// arrayIndex parses an array reference token; "-" (one past the end) is
+// only accepted when allowEnd is set
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+    if token == "-" && allowEnd {
+        return length, nil
+    }
+    if token == "" || (len(token) > 1 && token[0] == '0') {
+        return 0, fmt.Errorf("invalid array index %q", token)
+    }
+    index, err := strconv.Atoi(token)
+    if err != nil || index < 0 {
+        return 0, fmt.Errorf("invalid array index %q", token)
+    }
+    limit := length - 1
+    if allowEnd {
+        limit = length
+    }
+    if index > limit {
+        return 0, fmt.Errorf("array index %d out of range", index)
+    }
+    return index, nil
+}
+
+func getAt(doc interface{}, path []string) (interface{}, error) {
+    for _, token := range path {
+        switch node := doc.(type) {
+        case map[string]interface{}:
+            value, ok := node[token]
+            if !ok {
+                return nil, fmt.Errorf("path member %q not found", token)
+            }
+            doc = value
+        case []interface{}:
+            index, err := arrayIndex(token, len(node), false)
+            if err != nil {
+                return nil, err
+            }
+            doc = node[index]
+        default:
+            return nil, fmt.Errorf("path member %q not found", token)
+        }
+    }
+    return doc, nil
+}
+
+// updateParent runs change on the container that holds the last token of
+// path and stores the container it returns back in the document
+func updateParent(doc interface{}, path []string, change func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+    if len(path) == 1 {
+        return change(doc, path[0])
+    }
+
+    token := path[0]
+    switch node := doc.(type) {
+    case map[string]interface{}:
+        child, ok := node[token]
+        if !ok {
+            return nil, fmt.Errorf("path member %q not found", token)
+        }
+        updated, err := updateParent(child, path[1:], change)
+        if err != nil {
+            return nil, err
+        }
+        node[token] = updated
+        return node, nil
+    case []interface{}:
+        index, err := arrayIndex(token, len(node), false)
+        if err != nil {
+            return nil, err
+        }
+        updated, err := updateParent(node[index], path[1:], change)
+        if err != nil {
+            return nil, err
+        }
+        node[index] = updated
+        return node, nil
+    }
+    return nil, fmt.Errorf("path member %q not found", token)
+}
+
+func addAt(doc interface{}, path []string, value interface{}) (interface{}, error) {
+    if len(path) == 0 {
+        return value, nil
+    }
+    return updateParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+        switch node := parent.(type) {
+        case map[string]interface{}:
+            node[token] = value
+            return node, nil
+        case []interface{}:
+            index, err := arrayIndex(token, len(node), true)
+            if err != nil {
+                return nil, err
+            }
+            node = append(node, nil)
+            copy(node[index+1:], node[index:])
+            node[index] = value
+            return node, nil
+        }
+        return nil, fmt.Errorf("cannot add %q to a scalar", token)
+    })
+}
+
+func replaceAt(doc interface{}, path []string, value interface{}) (interface{}, error) {
+    if _, err := getAt(doc, path); err != nil {
+        return nil, err
+    }
+    if len(path) == 0 {
+        return value, nil
+    }
+    return updateParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+        switch node := parent.(type) {
+        case map[string]interface{}:
+            node[token] = value
+            return node, nil
+        case []interface{}:
+            index, _ := arrayIndex(token, len(node), false)
+            node[index] = value
+            return node, nil
+        }
+        return nil, fmt.Errorf("path member %q not found", token)
+    })
+}
+
+// removeAt deletes the value at path and returns it along with the document
+func removeAt(doc interface{}, path []string) (interface{}, interface{}, error) {
+    if len(path) == 0 {
+        return nil, nil, fmt.Errorf("cannot remove the whole document")
+    }
+
+    var removed interface{}
+    doc, err := updateParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+        switch node := parent.(type) {
+        case map[string]interface{}:
+            value, ok := node[token]
+            if !ok {
+                return nil, fmt.Errorf("path member %q not found", token)
+            }
+            removed = value
+            delete(node, token)
+            return node, nil
+        case []interface{}:
+            index, err := arrayIndex(token, len(node), false)
+            if err != nil {
+                return nil, err
+            }
+            removed = node[index]
+            return append(node[:index], node[index+1:]...), nil
+        }
+        return nil, fmt.Errorf("path member %q not found", token)
+    })
+    return doc, removed, err
+}
+
+// deepCopyJSON copies a decoded JSON value so the copy can be changed on its own
+func deepCopyJSON(value interface{}) interface{} {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        copied := make(map[string]interface{}, len(v))
+        for key, item := range v {
+            copied[key] = deepCopyJSON(item)
+        }
+        return copied
+    case []interface{}:
+        copied := make([]interface{}, len(v))
+        for i, item := range v {
+            copied[i] = deepCopyJSON(item)
+        }
+        return copied
+    }
+    return value
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+    "encoding/json"
+    "reflect"
+    "testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+    tests := []struct {
+        name  string
+        doc   string
+        patch string
+        want  string // empty when the patch must fail
+    }{
+        // RFC 6902 Appendix A
+        {"A.1 adding an object member",
+            `{"foo":"bar"}`,
+            `[{"op":"add","path":"/baz","value":"qux"}]`,
+            `{"baz":"qux","foo":"bar"}`},
+        {"A.2 adding an array element",
+            `{"foo":["bar","baz"]}`,
+            `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+            `{"foo":["bar","qux","baz"]}`},
+        {"A.3 removing an object member",
+            `{"baz":"qux","foo":"bar"}`,
+            `[{"op":"remove","path":"/baz"}]`,
+            `{"foo":"bar"}`},
+        {"A.4 removing an array element",
+            `{"foo":["bar","qux","baz"]}`,
+            `[{"op":"remove","path":"/foo/1"}]`,
+            `{"foo":["bar","baz"]}`},
+        {"A.5 replacing a value",
+            `{"baz":"qux","foo":"bar"}`,
+            `[{"op":"replace","path":"/baz","value":"boo"}]`,
+            `{"baz":"boo","foo":"bar"}`},
+        {"A.6 moving a value",
+            `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+            `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+            `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+        {"A.7 moving an array element",
+            `{"foo":["all","grass","cows","eat"]}`,
+            `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+            `{"foo":["all","cows","eat","grass"]}`},
+        {"A.8 testing a value: success",
+            `{"baz":"qux","foo":["a",2,"c"]}`,
+            `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+            `{"baz":"qux","foo":["a",2,"c"]}`},
+        {"A.9 testing a value: error",
+            `{"baz":"qux"}`,
+            `[{"op":"test","path":"/baz","value":"bar"}]`,
+            ``},
+        {"A.10 adding a nested member object",
+            `{"foo":"bar"}`,
+            `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+            `{"foo":"bar","child":{"grandchild":{}}}`},
+        {"A.11 ignoring unrecognized elements",
+            `{"foo":"bar"}`,
+            `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+            `{"foo":"bar","baz":"qux"}`},
+        {"A.12 adding to a nonexistent target",
+            `{"foo":"bar"}`,
+            `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+            ``},
+        {"A.14 ~ escape ordering",
+            `{"/":9,"~1":10}`,
+            `[{"op":"test","path":"/~01","value":10}]`,
+            `{"/":9,"~1":10}`},
+        {"A.15 comparing strings and numbers",
+            `{"/":9,"~1":10}`,
+            `[{"op":"test","path":"/~01","value":"10"}]`,
+            ``},
+        {"A.16 adding an array value",
+            `{"foo":["bar"]}`,
+            `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+            `{"foo":["bar",["abc","def"]]}`},
+
+        // Pointers and indexes
+        {"escaped slash in a member name",
+            `{"a/b":1}`,
+            `[{"op":"replace","path":"/a~1b","value":2}]`,
+            `{"a/b":2}`},
+        {"escaped tilde in a member name",
+            `{"m~n":1}`,
+            `[{"op":"remove","path":"/m~0n"}]`,
+            `{}`},
+        {"path without a leading slash",
+            `{"foo":"bar"}`,
+            `[{"op":"remove","path":"foo"}]`,
+            ``},
+        {"end index only for add",
+            `{"foo":["bar"]}`,
+            `[{"op":"replace","path":"/foo/-","value":"baz"}]`,
+            ``},
+        {"index past the end",
+            `{"foo":["bar"]}`,
+            `[{"op":"add","path":"/foo/2","value":"baz"}]`,
+            ``},
+        {"index equal to the length appends",
+            `{"foo":["bar"]}`,
+            `[{"op":"add","path":"/foo/1","value":"baz"}]`,
+            `{"foo":["bar","baz"]}`},
+        {"leading zero index",
+            `{"foo":["bar","baz"]}`,
+            `[{"op":"remove","path":"/foo/01"}]`,
+            ``},
+        {"negative index",
+            `{"foo":["bar","baz"]}`,
+            `[{"op":"remove","path":"/foo/-1"}]`,
+            ``},
+        {"replacing the whole document",
+            `{"foo":"bar"}`,
+            `[{"op":"replace","path":"","value":{"baz":1}}]`,
+            `{"baz":1}`},
+        {"removing the whole document",
+            `{"foo":"bar"}`,
+            `[{"op":"remove","path":""}]`,
+            ``},
+
+        // Operations
+        {"replacing a missing member",
+            `{"foo":"bar"}`,
+            `[{"op":"replace","path":"/baz","value":1}]`,
+            ``},
+        {"add without a value",
+            `{"foo":"bar"}`,
+            `[{"op":"add","path":"/baz"}]`,
+            ``},
+        {"add with a null value",
+            `{"foo":"bar"}`,
+            `[{"op":"add","path":"/baz","value":null}]`,
+            `{"foo":"bar","baz":null}`},
+        {"moving into a child",
+            `{"foo":{"bar":{}}}`,
+            `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`,
+            ``},
+        {"moving onto itself",
+            `{"foo":{"bar":1}}`,
+            `[{"op":"move","from":"/foo","path":"/foo"}]`,
+            `{"foo":{"bar":1}}`},
+        {"copy is independent of its source",
+            `{"foo":{"bar":1}}`,
+            `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+            `{"foo":{"bar":1},"baz":{"bar":2}}`},
+        {"testing objects ignores member order",
+            `{"foo":{"a":1,"b":[1,2]}}`,
+            `[{"op":"test","path":"/foo","value":{"b":[1,2],"a":1}}]`,
+            `{"foo":{"a":1,"b":[1,2]}}`},
+        {"testing arrays compares order",
+            `{"foo":[1,2]}`,
+            `[{"op":"test","path":"/foo","value":[2,1]}]`,
+            ``},
+        {"unknown op",
+            `{"foo":"bar"}`,
+            `[{"op":"merge","path":"/foo","value":1}]`,
+            ``},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var doc interface{}
+            if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+                t.Fatal(err)
+            }
+            var ops []PatchOperation
+            if err := json.Unmarshal([]byte(tt.patch), &ops); err != nil {
+                t.Fatal(err)
+            }
+
+            got, err := ApplyJSONPatch(doc, ops)
+            if tt.want == "" {
+                if err == nil {
+                    t.Fatalf("ApplyJSONPatch() = %v, want an error", got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("ApplyJSONPatch() error = %v", err)
+            }
+            var want interface{}
+            if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+                t.Fatal(err)
+            }
+            if !reflect.DeepEqual(got, want) {
+                t.Errorf("ApplyJSONPatch() = %v, want %v", got, want)
+            }
+        })
+    }
+}
+
+func TestApplyJSONPatchError(t *testing.T) {
+    ops := []PatchOperation{
+        {Op: "add", Path: "/baz", Value: json.RawMessage(`1`)},
+        {Op: "test", Path: "/foo", Value: json.RawMessage(`"qux"`)},
+    }
+    _, err := ApplyJSONPatch(map[string]interface{}{"foo": "bar"}, ops)
+    patchErr, ok := err.(*PatchError)
+    if !ok {
+        t.Fatalf("error = %v, want a *PatchError", err)
+    }
+    if patchErr.Index != 1 || patchErr.Op != "test" || !patchErr.TestFailed {
+        t.Errorf("error = %+v, want the failed test at index 1", patchErr)
+    }
+
+    _, err = ApplyJSONPatch(map[string]interface{}{}, []PatchOperation{{Op: "remove", Path: "/foo"}})
+    if patchErr, ok := err.(*PatchError); !ok || patchErr.TestFailed {
+        t.Errorf("error = %v, want a *PatchError that is not a failed test", err)
+    }
+}
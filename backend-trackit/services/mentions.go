@@ -0,0 +1,42 @@
+package services
+
+import (
+    "regexp"
+    "strings"
+)
+
+// mentionPattern matches @alice@example.com and @alice. Email mentions are
+// tried first so the domain isn't read as a second handle.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}|[A-Za-z0-9_][A-Za-z0-9._-]*)`)
+
+// Mentions lists the people mentioned in a text
+type Mentions struct {
+    Emails  []string
+    Handles []string
+}
+
+// ParseMentions extracts de-duplicated, lowercased @mentions from text
+func ParseMentions(text string) Mentions {
+    var mentions Mentions
+    seen := make(map[string]bool)
+
+    for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+        token := strings.ToLower(strings.TrimRight(match[1], "."))
+        if token == "" || seen[token] {
+            continue
+        }
+        seen[token] = true
+
+        if strings.Contains(token, "@") {
+            mentions.Emails = append(mentions.Emails, token)
+        } else {
+            mentions.Handles = append(mentions.Handles, token)
+        }
+    }
+    return mentions
+}
+
+// Empty reports whether nobody was mentioned
+func (m Mentions) Empty() bool {
+    return len(m.Emails) == 0 && len(m.Handles) == 0
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+    "fmt"
+    "strings"
+)
+
+// rankDigits are the digits ranks are written in, in ascending byte order,
+// so ranks compare correctly as plain strings.
+//
+// Ranks order tasks within a board column. A rank is a base-36 fraction
+// written without its leading "0." and without trailing zeros, which leaves
+// room for another rank between any two distinct ranks. Ranks grow longer
+// as tasks are moved into the same gap; RankSequence hands out short,
+// evenly spaced ranks again.
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// RankBetween returns a rank that sorts after before and ahead of after.
+// An empty before means the start of the column, an empty after its end.
+func RankBetween(before, after string) (string, error) {
+    for _, rank := range []string{before, after} {
+        if err := checkRank(rank); err != nil {
+            return "", err
+        }
+    }
+    if before != "" && after != "" && before >= after {
+        return "", fmt.Errorf("rank %q does not sort before %q", before, after)
+    }
+    return rankMidpoint(before, after), nil
+}
+
+// RankSequence returns n ascending ranks spread evenly over the whole range,
+// all of the same short length
+func RankSequence(n int) []string {
+    // Leave a gap of at least len(rankDigits) between neighbours
+    width, span := 1, len(rankDigits)
+    for span < (n+1)*len(rankDigits) {
+        width++
+        span *= len(rankDigits)
+    }
+
+    ranks := make([]string, n)
+    step := span / (n + 1)
+    for i := range ranks {
+        ranks[i] = strings.TrimRight(formatRank(step*(i+1), width), "0")
+    }
+    return ranks
+}
+
+// IsValidRank reports whether rank is a well-formed, non-empty rank
+func IsValidRank(rank string) bool {
+    return rank != "" && checkRank(rank) == nil
+}
+
+func checkRank(rank string) error {
+    for i := 0; i < len(rank); i++ {
+        if strings.IndexByte(rankDigits, rank[i]) < 0 {
+            return fmt.Errorf("rank %q contains an invalid character", rank)
+        }
+    }
+    if strings.HasSuffix(rank, "0") {
+        return fmt.Errorf("rank %q has a trailing zero", rank)
+    }
+    return nil
+}
+
+// rankMidpoint returns a rank strictly between a and b, where a < b and an
+// empty b stands for the end of the range
+func rankMidpoint(a, b string) string {
+    if b != "" {
+        // Keep the common prefix, reading a as padded with zeros
+        n := 0
+        for n < len(b) && rankDigitAt(a, n) == b[n] {
+            n++
+        }
+        if n > 0 {
+            rest := ""
+            if n < len(a) {
+                rest = a[n:]
+            }
+            return b[:n] + rankMidpoint(rest, b[n:])
+        }
+    }
+
+    low := 0
+    if a != "" {
+        low = strings.IndexByte(rankDigits, a[0])
+    }
+    high := len(rankDigits)
+    if b != "" {
+        high = strings.IndexByte(rankDigits, b[0])
+    }
+    if high-low > 1 {
+        return string(rankDigits[(low+high+1)/2])
+    }
+
+    // The first digits are adjacent. If b goes on, its first digit alone
+    // sorts between them; otherwise continue after a's first digit.
+    if len(b) > 1 {
+        return b[:1]
+    }
+    rest := ""
+    if len(a) > 1 {
+        rest = a[1:]
+    }
+    return string(rankDigits[low]) + rankMidpoint(rest, "")
+}
+
+func rankDigitAt(rank string, i int) byte {
+    if i < len(rank) {
+        return rank[i]
+    }
+    return rankDigits[0]
+}
+
+// formatRank writes n in base 36, zero-padded to width digits
+func formatRank(n, width int) string {
+    digits := make([]byte, width)
+    for i := width - 1; i >= 0; i-- {
+        digits[i] = rankDigits[n%len(rankDigits)]
+        n /= len(rankDigits)
+    }
+    return string(digits)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+    "math/rand"
+    "sort"
+    "strings"
+    "testing"
+)
+
+func TestRankBetween(t *testing.T) {
+    tests := []struct {
+        name          string
+        before, after string
+        want          string // empty when RankBetween must fail
+    }{
+        {"empty column", "", "", "i"},
+        {"start of the column", "", "i", "9"},
+        {"end of the column", "i", "", "r"},
+        {"wide gap", "a", "z", "n"},
+        {"adjacent digits", "1", "2", "1i"},
+        {"adjacent digits, longer after", "1", "21", "2"},
+        {"after the last digit", "z", "", "zi"},
+        {"before the first digit", "", "1", "0i"},
+        {"common prefix with an empty before", "", "01", "00i"},
+        {"common prefix", "a1", "a2", "a1i"},
+        {"before is a prefix of after", "a", "ab", "a6"},
+        {"zero-padded prefix", "a", "a01", "a00i"},
+        {"long before", "azzz", "b", "azzzi"},
+        {"trailing zero before", "0", "1", ""},
+        {"trailing zero after", "", "10", ""},
+        {"uppercase digit", "A", "", ""},
+        {"invalid character", "", "a-b", ""},
+        {"reversed", "b", "a", ""},
+        {"equal", "a", "a", ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := RankBetween(tt.before, tt.after)
+            if tt.want == "" {
+                if err == nil {
+                    t.Fatalf("RankBetween(%q, %q) = %q, want an error", tt.before, tt.after, got)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("RankBetween(%q, %q) error = %v", tt.before, tt.after, err)
+            }
+            if got != tt.want {
+                t.Errorf("RankBetween(%q, %q) = %q, want %q", tt.before, tt.after, got, tt.want)
+            }
+        })
+    }
+}
+
+// checkBetween fails unless rank is a valid rank strictly between before
+// and after, where empty bounds are the ends of the column
+func checkBetween(t *testing.T, before, after, rank string) {
+    t.Helper()
+    if !IsValidRank(rank) {
+        t.Fatalf("RankBetween(%q, %q) = %q, which is not a valid rank", before, after, rank)
+    }
+    if rank <= before || (after != "" && rank >= after) {
+        t.Fatalf("RankBetween(%q, %q) = %q, which is out of order", before, after, rank)
+    }
+}
+
+func TestRankBetweenOrders(t *testing.T) {
+    random := rand.New(rand.NewSource(1))
+    randomRank := func() string {
+        var b strings.Builder
+        for n := 1 + random.Intn(6); n > 0; n-- {
+            b.WriteByte(rankDigits[random.Intn(len(rankDigits))])
+        }
+        return strings.TrimRight(b.String(), "0")
+    }
+
+    for i := 0; i < 10000; i++ {
+        a, b := randomRank(), randomRank()
+        if a > b {
+            a, b = b, a
+        }
+        switch random.Intn(4) {
+        case 0:
+            a = ""
+        case 1:
+            b = ""
+        }
+        if a != "" && a == b {
+            continue
+        }
+        rank, err := RankBetween(a, b)
+        if err != nil {
+            t.Fatalf("RankBetween(%q, %q) error = %v", a, b, err)
+        }
+        checkBetween(t, a, b, rank)
+    }
+}
+
+// Moving task after task into the same gap keeps finding room
+func TestRankBetweenRepeatedInserts(t *testing.T) {
+    for _, tt := range []struct {
+        name string
+        next func(low, high, rank string) (string, string)
+    }{
+        {"always just after the lower bound", func(low, high, rank string) (string, string) { return low, rank }},
+        {"always just before the upper bound", func(low, high, rank string) (string, string) { return rank, high }},
+    } {
+        t.Run(tt.name, func(t *testing.T) {
+            low, high := "a", "b"
+            for i := 0; i < 200; i++ {
+                rank, err := RankBetween(low, high)
+                if err != nil {
+                    t.Fatalf("RankBetween(%q, %q) error = %v", low, high, err)
+                }
+                checkBetween(t, low, high, rank)
+                low, high = tt.next(low, high, rank)
+            }
+        })
+    }
+}
+
+func TestRankSequence(t *testing.T) {
+    if got := RankSequence(0); len(got) != 0 {
+        t.Errorf("RankSequence(0) = %v, want none", got)
+    }
+    for _, n := range []int{1, 2, 35, 36, 1000, 50000} {
+        ranks := RankSequence(n)
+        if len(ranks) != n {
+            t.Fatalf("RankSequence(%d) returned %d ranks", n, len(ranks))
+        }
+        if !sort.StringsAreSorted(ranks) {
+            t.Errorf("RankSequence(%d) is not ascending", n)
+        }
+        for i, rank := range ranks {
+            if !IsValidRank(rank) {
+                t.Fatalf("RankSequence(%d)[%d] = %q, which is not a valid rank", n, i, rank)
+            }
+            if i > 0 && rank == ranks[i-1] {
+                t.Fatalf("RankSequence(%d) repeats %q", n, rank)
+            }
+        }
+    }
+}
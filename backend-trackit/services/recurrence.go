@@ -0,0 +1,446 @@
+package services
+
+import (
+    "fmt"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Rule frequencies (the FREQ part of an RRULE)
+const (
+    FreqDaily   = "DAILY"
+    FreqWeekly  = "WEEKLY"
+    FreqMonthly = "MONTHLY"
+    FreqYearly  = "YEARLY"
+)
+
+// maxRecurrencePeriods stops the search for occurrences of a rule that
+// matches rarely or never
+const maxRecurrencePeriods = 50000
+
+var weekdayCodes = map[string]time.Weekday{
+    "SU": time.Sunday,
+    "MO": time.Monday,
+    "TU": time.Tuesday,
+    "WE": time.Wednesday,
+    "TH": time.Thursday,
+    "FR": time.Friday,
+    "SA": time.Saturday,
+}
+
+var byDayPattern = regexp.MustCompile(`^([+-]?\d{1,2})?([A-Z]{2})$`)
+
+// WeekdayNum is one BYDAY entry: a weekday, optionally the nth (or, when
+// negative, nth from last) of it in the month
+type WeekdayNum struct {
+    Ordinal int
+    Day     time.Weekday
+}
+
+// RRule is a parsed RFC 5545 recurrence rule. The supported parts are
+// FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYSETPOS and WKST.
+type RRule struct {
+    Freq       string
+    Interval   int
+    Count      int
+    Until      time.Time // zero when the rule has no end date
+    ByDay      []WeekdayNum
+    ByMonthDay []int
+    BySetPos   []int
+    WeekStart  time.Weekday
+}
+
+// Recurrence is a rule anchored at a first occurrence and evaluated in a
+// time zone, less the excluded dates
+type Recurrence struct {
+    rule     *RRule
+    start    time.Time
+    location *time.Location
+    exdates  map[int64]bool
+}
+
+// FrequencyRule returns the rule for one of the simple frequencies, daily,
+// weekly or monthly
+func FrequencyRule(frequency string) (string, error) {
+    switch frequency {
+    case "daily", "weekly", "monthly":
+        return "FREQ=" + strings.ToUpper(frequency), nil
+    }
+    return "", fmt.Errorf("unknown frequency %q", frequency)
+}
+
+// ParseRRule parses an RRULE value such as "FREQ=MONTHLY;BYDAY=TU;BYSETPOS=2",
+// with or without the "RRULE:" prefix. A floating UNTIL is read in loc.
+func ParseRRule(text string, loc *time.Location) (*RRule, error) {
+    text = strings.TrimPrefix(strings.TrimSpace(text), "RRULE:")
+    if text == "" {
+        return nil, fmt.Errorf("rule is empty")
+    }
+
+    rule := &RRule{Interval: 1, WeekStart: time.Monday}
+    seen := make(map[string]bool)
+    for _, part := range strings.Split(text, ";") {
+        name, value, ok := strings.Cut(part, "=")
+        name = strings.ToUpper(strings.TrimSpace(name))
+        value = strings.ToUpper(strings.TrimSpace(value))
+        if !ok || value == "" {
+            return nil, fmt.Errorf("invalid rule part %q", part)
+        }
+        if seen[name] {
+            return nil, fmt.Errorf("%s is given more than once", name)
+        }
+        seen[name] = true
+
+        var err error
+        switch name {
+        case "FREQ":
+            switch value {
+            case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+                rule.Freq = value
+            default:
+                err = fmt.Errorf("unsupported FREQ %q", value)
+            }
+        case "INTERVAL":
+            rule.Interval, err = parseRulePositive(name, value)
+        case "COUNT":
+            rule.Count, err = parseRulePositive(name, value)
+        case "UNTIL":
+            rule.Until, err = parseRuleUntil(value, loc)
+        case "BYDAY":
+            rule.ByDay, err = parseRuleByDay(value)
+        case "BYMONTHDAY":
+            rule.ByMonthDay, err = parseRuleInts(name, value, 31)
+        case "BYSETPOS":
+            rule.BySetPos, err = parseRuleInts(name, value, 366)
+        case "WKST":
+            day, ok := weekdayCodes[value]
+            if !ok {
+                err = fmt.Errorf("invalid WKST %q", value)
+            }
+            rule.WeekStart = day
+        default:
+            err = fmt.Errorf("unsupported rule part %s", name)
+        }
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if err := rule.check(); err != nil {
+        return nil, err
+    }
+    return rule, nil
+}
+
+// check rejects combinations of parts the evaluator does not give a meaning to
+func (r *RRule) check() error {
+    if r.Freq == "" {
+        return fmt.Errorf("FREQ is required")
+    }
+    if r.Count > 0 && !r.Until.IsZero() {
+        return fmt.Errorf("COUNT and UNTIL cannot both be given")
+    }
+    if r.Freq == FreqYearly && (len(r.ByDay) > 0 || len(r.ByMonthDay) > 0) {
+        return fmt.Errorf("BYDAY and BYMONTHDAY are not supported with FREQ=YEARLY")
+    }
+    if r.Freq == FreqWeekly && len(r.ByMonthDay) > 0 {
+        return fmt.Errorf("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+    }
+    if r.Freq != FreqMonthly {
+        for _, day := range r.ByDay {
+            if day.Ordinal != 0 {
+                return fmt.Errorf("numbered BYDAY values need FREQ=MONTHLY")
+            }
+        }
+    }
+    if len(r.BySetPos) > 0 && len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+        return fmt.Errorf("BYSETPOS needs BYDAY or BYMONTHDAY")
+    }
+    return nil
+}
+
+// NewRecurrence evaluates rule from start in the named IANA time zone
+// (UTC when empty). Occurrences keep start's wall-clock time of day in that
+// zone across daylight saving changes; exdates are left out.
+func NewRecurrence(rule string, start time.Time, timezone string, exdates []time.Time) (*Recurrence, error) {
+    location := time.UTC
+    if timezone != "" {
+        loaded, err := time.LoadLocation(timezone)
+        if err != nil {
+            return nil, fmt.Errorf("unknown time zone %q", timezone)
+        }
+        location = loaded
+    }
+    if start.IsZero() {
+        return nil, fmt.Errorf("a start time is required")
+    }
+
+    parsed, err := ParseRRule(rule, location)
+    if err != nil {
+        return nil, err
+    }
+
+    excluded := make(map[int64]bool, len(exdates))
+    for _, date := range exdates {
+        excluded[date.Unix()] = true
+    }
+    return &Recurrence{
+        rule:     parsed,
+        start:    start.In(location).Truncate(time.Second),
+        location: location,
+        exdates:  excluded,
+    }, nil
+}
+
+// Location is the time zone the recurrence is evaluated in
+func (r *Recurrence) Location() *time.Location {
+    return r.location
+}
+
+// Each calls fn with every occurrence after the given time, in order, until
+// fn returns false or the rule runs out
+func (r *Recurrence) Each(after time.Time, fn func(time.Time) bool) {
+    count := 0
+    for period := 0; period < maxRecurrencePeriods; period++ {
+        for _, occurrence := range r.periodOccurrences(period) {
+            if occurrence.Before(r.start) {
+                continue
+            }
+            if !r.rule.Until.IsZero() && occurrence.After(r.rule.Until) {
+                return
+            }
+            // COUNT counts the occurrences the rule makes, excluded or not
+            count++
+            if occurrence.After(after) && !r.exdates[occurrence.Unix()] {
+                if !fn(occurrence) {
+                    return
+                }
+            }
+            if r.rule.Count > 0 && count >= r.rule.Count {
+                return
+            }
+        }
+    }
+}
+
+// Occurrences returns up to limit occurrences after the given time
+func (r *Recurrence) Occurrences(after time.Time, limit int) []time.Time {
+    occurrences := []time.Time{}
+    if limit <= 0 {
+        return occurrences
+    }
+    r.Each(after, func(t time.Time) bool {
+        occurrences = append(occurrences, t)
+        return len(occurrences) < limit
+    })
+    return occurrences
+}
+
+// Next returns the first occurrence after the given time, or false once the
+// rule has run out
+func (r *Recurrence) Next(after time.Time) (time.Time, bool) {
+    occurrences := r.Occurrences(after, 1)
+    if len(occurrences) == 0 {
+        return time.Time{}, false
+    }
+    return occurrences[0], true
+}
+
+// periodOccurrences lists, in order, the occurrences in the nth period
+// (day, week, month or year, times INTERVAL) counted from the start
+func (r *Recurrence) periodOccurrences(n int) []time.Time {
+    year, month, day := r.start.Date()
+    step := n * r.rule.Interval
+
+    var days []time.Time
+    switch r.rule.Freq {
+    case FreqDaily:
+        date := r.date(year, month, day+step)
+        if r.matchesWeekday(date) && r.matchesMonthDay(date) {
+            days = append(days, date)
+        }
+    case FreqWeekly:
+        offset := (int(r.start.Weekday()) - int(r.rule.WeekStart) + 7) % 7
+        weekStart := r.date(year, month, day-offset+7*step)
+        for i := 0; i < 7; i++ {
+            date := weekStart.AddDate(0, 0, i)
+            if len(r.rule.ByDay) == 0 && date.Weekday() != r.start.Weekday() {
+                continue
+            }
+            if r.matchesWeekday(date) {
+                days = append(days, date)
+            }
+        }
+    case FreqMonthly:
+        first := r.date(year, month+time.Month(step), 1)
+        length := first.AddDate(0, 1, -1).Day()
+        for i := 1; i <= length; i++ {
+            date := r.date(first.Year(), first.Month(), i)
+            if len(r.rule.ByDay) == 0 && len(r.rule.ByMonthDay) == 0 {
+                if i == day {
+                    days = append(days, date)
+                }
+                continue
+            }
+            if r.matchesMonthDay(date) && r.matchesNumberedWeekday(date, length) {
+                days = append(days, date)
+            }
+        }
+    case FreqYearly:
+        // February 29 only occurs in leap years
+        date := r.date(year+step, month, day)
+        if date.Month() == month {
+            days = append(days, date)
+        }
+    }
+
+    days = applySetPos(days, r.rule.BySetPos)
+
+    occurrences := make([]time.Time, len(days))
+    hour, minute, second := r.start.Clock()
+    for i, date := range days {
+        occurrences[i] = time.Date(date.Year(), date.Month(), date.Day(), hour, minute, second, 0, r.location)
+    }
+    return occurrences
+}
+
+// date is midnight of a (possibly denormalized) date in the rule's zone
+func (r *Recurrence) date(year int, month time.Month, day int) time.Time {
+    return time.Date(year, month, day, 0, 0, 0, 0, r.location)
+}
+
+func (r *Recurrence) matchesWeekday(date time.Time) bool {
+    if len(r.rule.ByDay) == 0 {
+        return true
+    }
+    for _, day := range r.rule.ByDay {
+        if day.Day == date.Weekday() {
+            return true
+        }
+    }
+    return false
+}
+
+// matchesNumberedWeekday checks BYDAY in a month of the given length,
+// where 2TU is the second Tuesday and -1FR the last Friday
+func (r *Recurrence) matchesNumberedWeekday(date time.Time, length int) bool {
+    if len(r.rule.ByDay) == 0 {
+        return true
+    }
+    fromStart := (date.Day()-1)/7 + 1
+    fromEnd := -((length-date.Day())/7 + 1)
+    for _, day := range r.rule.ByDay {
+        if day.Day != date.Weekday() {
+            continue
+        }
+        if day.Ordinal == 0 || day.Ordinal == fromStart || day.Ordinal == fromEnd {
+            return true
+        }
+    }
+    return false
+}
+
+// matchesMonthDay checks BYMONTHDAY, where -1 is the last day of the month
+func (r *Recurrence) matchesMonthDay(date time.Time) bool {
+    if len(r.rule.ByMonthDay) == 0 {
+        return true
+    }
+    length := date.AddDate(0, 1, -date.Day()).Day()
+    for _, day := range r.rule.ByMonthDay {
+        if day == date.Day() || (day < 0 && length+day+1 == date.Day()) {
+            return true
+        }
+    }
+    return false
+}
+
+// applySetPos keeps the BYSETPOS positions of a period's sorted dates
+func applySetPos(days []time.Time, positions []int) []time.Time {
+    if len(positions) == 0 {
+        return days
+    }
+    picked := make(map[int]bool)
+    for _, pos := range positions {
+        index := pos - 1
+        if pos < 0 {
+            index = len(days) + pos
+        }
+        if index >= 0 && index < len(days) {
+            picked[index] = true
+        }
+    }
+
+    indexes := make([]int, 0, len(picked))
+    for index := range picked {
+        indexes = append(indexes, index)
+    }
+    sort.Ints(indexes)
+
+    kept := make([]time.Time, len(indexes))
+    for i, index := range indexes {
+        kept[i] = days[index]
+    }
+    return kept
+}
+
+func parseRulePositive(name, value string) (int, error) {
+    n, err := strconv.Atoi(value)
+    if err != nil || n < 1 {
+        return 0, fmt.Errorf("%s must be a positive integer", name)
+    }
+    return n, nil
+}
+
+// parseRuleInts parses a list of non-zero integers within ±limit
+func parseRuleInts(name, value string, limit int) ([]int, error) {
+    var values []int
+    for _, item := range strings.Split(value, ",") {
+        n, err := strconv.Atoi(item)
+        if err != nil || n == 0 || n < -limit || n > limit {
+            return nil, fmt.Errorf("%s values must be between -%d and %d, excluding 0", name, limit, limit)
+        }
+        values = append(values, n)
+    }
+    return values, nil
+}
+
+func parseRuleByDay(value string) ([]WeekdayNum, error) {
+    var days []WeekdayNum
+    for _, item := range strings.Split(value, ",") {
+        match := byDayPattern.FindStringSubmatch(item)
+        if match == nil {
+            return nil, fmt.Errorf("invalid BYDAY value %q", item)
+        }
+        day, ok := weekdayCodes[match[2]]
+        if !ok {
+            return nil, fmt.Errorf("invalid BYDAY value %q", item)
+        }
+        ordinal := 0
+        if match[1] != "" {
+            ordinal, _ = strconv.Atoi(match[1])
+            if ordinal == 0 || ordinal < -5 || ordinal > 5 {
+                return nil, fmt.Errorf("invalid BYDAY value %q", item)
+            }
+        }
+        days = append(days, WeekdayNum{Ordinal: ordinal, Day: day})
+    }
+    return days, nil
+}
+
+// parseRuleUntil reads UNTIL as a UTC time (20261231T170000Z), a floating
+// local time or a date, which includes the whole day
+func parseRuleUntil(value string, loc *time.Location) (time.Time, error) {
+    if t, err := time.Parse("20060102T150405Z", value); err == nil {
+        return t, nil
+    }
+    if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+        return t, nil
+    }
+    if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+        return t.AddDate(0, 0, 1).Add(-time.Second), nil
+    }
+    return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
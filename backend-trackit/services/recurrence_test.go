@@ -0,0 +1,206 @@
+package services
+
+import (
+    "testing"
+    "time"
+    _ "time/tzdata" // the DST cases need zone data on any machine
+)
+
+func TestRecurrenceOccurrences(t *testing.T) {
+    start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+    day := func(month time.Month, d int) time.Time {
+        return time.Date(2026, month, d, 9, 0, 0, 0, time.UTC)
+    }
+
+    tests := []struct {
+        name    string
+        rule    string
+        start   time.Time
+        exdates []time.Time
+        want    []time.Time
+        ends    bool // the rule has no occurrences past want
+    }{
+        {
+            name:  "second Tuesday with BYSETPOS",
+            rule:  "FREQ=MONTHLY;BYDAY=TU;BYSETPOS=2",
+            start: start,
+            want:  []time.Time{day(1, 13), day(2, 10), day(3, 10), day(4, 14)},
+        },
+        {
+            name:  "second Tuesday with an ordinal",
+            rule:  "FREQ=MONTHLY;BYDAY=2TU",
+            start: start,
+            want:  []time.Time{day(1, 13), day(2, 10), day(3, 10), day(4, 14)},
+        },
+        {
+            name:  "last Friday",
+            rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+            start: start,
+            want:  []time.Time{day(1, 30), day(2, 27), day(3, 27), day(4, 24)},
+        },
+        {
+            name:  "last business day",
+            rule:  "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+            start: start,
+            want:  []time.Time{day(1, 30), day(2, 27), day(3, 31), day(4, 30), day(5, 29)},
+        },
+        {
+            name:  "BYMONTHDAY=31 skips short months",
+            rule:  "FREQ=MONTHLY;BYMONTHDAY=31",
+            start: start,
+            want:  []time.Time{day(1, 31), day(3, 31), day(5, 31), day(7, 31), day(8, 31)},
+        },
+        {
+            name:  "last day of the month",
+            rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+            start: start,
+            want:  []time.Time{day(1, 31), day(2, 28), day(3, 31), day(4, 30)},
+        },
+        {
+            name:  "month-end start without BYMONTHDAY skips short months",
+            rule:  "FREQ=MONTHLY",
+            start: day(1, 31),
+            want:  []time.Time{day(1, 31), day(3, 31), day(5, 31)},
+        },
+        {
+            name:  "weekdays every other week",
+            rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+            start: start,
+            want:  []time.Time{day(1, 2), day(1, 12), day(1, 16), day(1, 26)},
+        },
+        {
+            name:    "EXDATE is left out",
+            rule:    "FREQ=MONTHLY;BYDAY=2TU",
+            start:   start,
+            exdates: []time.Time{day(2, 10)},
+            want:    []time.Time{day(1, 13), day(3, 10), day(4, 14)},
+        },
+        {
+            name:    "COUNT includes excluded dates",
+            rule:    "FREQ=DAILY;COUNT=3",
+            start:   start,
+            exdates: []time.Time{day(1, 2)},
+            want:    []time.Time{day(1, 1), day(1, 3)},
+            ends:    true,
+        },
+        {
+            name:  "UNTIL date includes that day",
+            rule:  "FREQ=DAILY;UNTIL=20260103",
+            start: start,
+            want:  []time.Time{day(1, 1), day(1, 2), day(1, 3)},
+            ends:  true,
+        },
+        {
+            name:  "February 29 only in leap years",
+            rule:  "FREQ=YEARLY",
+            start: time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+            want: []time.Time{
+                time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+                time.Date(2028, 2, 29, 9, 0, 0, 0, time.UTC),
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            recurrence, err := NewRecurrence(tt.rule, tt.start, "", tt.exdates)
+            if err != nil {
+                t.Fatalf("NewRecurrence() error = %v", err)
+            }
+            limit := len(tt.want)
+            if tt.ends {
+                limit++
+            }
+            got := recurrence.Occurrences(tt.start.Add(-time.Second), limit)
+            if len(got) != len(tt.want) {
+                t.Fatalf("Occurrences() = %v, want %v", got, tt.want)
+            }
+            for i := range got {
+                if !got[i].Equal(tt.want[i]) {
+                    t.Errorf("occurrence %d = %v, want %v", i, got[i], tt.want[i])
+                }
+            }
+        })
+    }
+}
+
+// Occurrences keep their wall-clock time when daylight saving time starts
+// or ends between them
+func TestRecurrenceKeepsWallClockAcrossDST(t *testing.T) {
+    loc, err := time.LoadLocation("America/New_York")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        name  string
+        rule  string
+        start time.Time
+        gap   time.Duration // between the first two occurrences
+    }{
+        {"spring forward", "FREQ=DAILY", time.Date(2026, 3, 7, 9, 0, 0, 0, loc), 23 * time.Hour},
+        {"fall back", "FREQ=DAILY", time.Date(2026, 10, 31, 9, 0, 0, 0, loc), 25 * time.Hour},
+        {"weekly over the change", "FREQ=WEEKLY", time.Date(2026, 3, 2, 9, 0, 0, 0, loc), 7*24*time.Hour - time.Hour},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            recurrence, err := NewRecurrence(tt.rule, tt.start.UTC(), "America/New_York", nil)
+            if err != nil {
+                t.Fatalf("NewRecurrence() error = %v", err)
+            }
+            got := recurrence.Occurrences(tt.start.Add(-time.Second), 3)
+            if len(got) != 3 {
+                t.Fatalf("Occurrences() = %v, want 3", got)
+            }
+            for _, occurrence := range got {
+                if hour, minute, _ := occurrence.In(loc).Clock(); hour != 9 || minute != 0 {
+                    t.Errorf("occurrence %v is at %02d:%02d local time, want 09:00", occurrence, hour, minute)
+                }
+            }
+            if gap := got[1].Sub(got[0]); gap != tt.gap {
+                t.Errorf("gap = %v, want %v", gap, tt.gap)
+            }
+        })
+    }
+}
+
+func TestParseRRuleRejects(t *testing.T) {
+    rules := []string{
+        "",
+        "INTERVAL=2",
+        "FREQ=HOURLY",
+        "FREQ=WEEKLY;BYDAY=2TU",
+        "FREQ=DAILY;BYDAY=-1FR",
+        "FREQ=MONTHLY;BYDAY=6TU",
+        "FREQ=MONTHLY;BYDAY=0TU",
+        "FREQ=MONTHLY;BYDAY=XX",
+        "FREQ=WEEKLY;BYMONTHDAY=1",
+        "FREQ=YEARLY;BYDAY=MO",
+        "FREQ=MONTHLY;BYMONTHDAY=0",
+        "FREQ=MONTHLY;BYMONTHDAY=32",
+        "FREQ=MONTHLY;BYSETPOS=1",
+        "FREQ=DAILY;COUNT=2;UNTIL=20260101",
+        "FREQ=DAILY;INTERVAL=0",
+        "FREQ=DAILY;FREQ=WEEKLY",
+        "FREQ=DAILY;BYHOUR=9",
+        "FREQ=DAILY;UNTIL=tomorrow",
+    }
+    for _, rule := range rules {
+        if _, err := ParseRRule(rule, time.UTC); err == nil {
+            t.Errorf("ParseRRule(%q) accepted the rule", rule)
+        }
+    }
+}
+
+func TestRecurrenceRunsOut(t *testing.T) {
+    start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+    recurrence, err := NewRecurrence("RRULE:FREQ=DAILY;COUNT=2", start, "", nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if next, ok := recurrence.Next(start); !ok || !next.Equal(start.AddDate(0, 0, 1)) {
+        t.Errorf("Next(start) = %v, %v, want the following day", next, ok)
+    }
+    if next, ok := recurrence.Next(start.AddDate(0, 0, 1)); ok {
+        t.Errorf("Next() after the last occurrence = %v, want none", next)
+    }
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+    "sort"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "backend-trackit/models"
+)
+
+// TaskRole describes how a user is related to a task. Every task endpoint
+// resolves the caller's role first and asks it what is allowed, so the
+// access rules live in this file only.
+type TaskRole int
+
+const (
+    // TaskRoleNone means the user can't see the task at all
+    TaskRoleNone TaskRole = iota
+    // TaskRoleAssignee can read the task and move it along
+    TaskRoleAssignee
+    // TaskRoleCreator owns the task and can do anything with it
+    TaskRoleCreator
+)
+
+// assigneeEditableFields are the bson fields an assignee may change
+var assigneeEditableFields = map[string]bool{
+    "status":   true,
+    "progress": true,
+    "rank":     true,
+}
+
+// immutableTaskFields can't be changed through an update by anyone
+var immutableTaskFields = map[string]bool{
+    "_id":        true,
+    "created_by": true,
+    "created_at": true,
+}
+
+// RoleForTask returns the role the user holds on the task
+func RoleForTask(task models.Task, userID primitive.ObjectID) TaskRole {
+    if userID.IsZero() {
+        return TaskRoleNone
+    }
+    if task.CreatedBy == userID {
+        return TaskRoleCreator
+    }
+    if task.IsAssignedTo(userID) {
+        return TaskRoleAssignee
+    }
+    return TaskRoleNone
+}
+
+func (r TaskRole) String() string {
+    switch r {
+    case TaskRoleCreator:
+        return "creator"
+    case TaskRoleAssignee:
+        return "assignee"
+    default:
+        return "none"
+    }
+}
+
+// CanView reports whether the task may be returned to the user
+func (r TaskRole) CanView() bool {
+    return r != TaskRoleNone
+}
+
+// CanDelete reports whether the user may delete the task
+func (r TaskRole) CanDelete() bool {
+    return r == TaskRoleCreator
+}
+
+// CanArchive reports whether the user may archive or unarchive the task
+func (r TaskRole) CanArchive() bool {
+    return r == TaskRoleCreator
+}
+
+// CanUpdateField reports whether the user may change a single bson field
+func (r TaskRole) CanUpdateField(field string) bool {
+    if immutableTaskFields[field] {
+        return false
+    }
+    switch r {
+    case TaskRoleCreator:
+        return true
+    case TaskRoleAssignee:
+        return assigneeEditableFields[field]
+    default:
+        return false
+    }
+}
+
+// DeniedFields returns, sorted, the bson fields the role may not update.
+// An empty result means the whole update is allowed.
+func (r TaskRole) DeniedFields(fields []string) []string {
+    var denied []string
+    for _, field := range fields {
+        if !r.CanUpdateField(field) {
+            denied = append(denied, field)
+        }
+    }
+    sort.Strings(denied)
+    return denied
+}
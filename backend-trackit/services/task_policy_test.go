@@ -0,0 +1,109 @@
+package services
+
+import (
+    "reflect"
+    "testing"
+
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "backend-trackit/models"
+)
+
+func TestRoleForTask(t *testing.T) {
+    creator, assignee, outsider := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+    task := models.Task{CreatedBy: creator, AssignedTo: []primitive.ObjectID{assignee}}
+
+    tests := []struct {
+        name   string
+        userID primitive.ObjectID
+        want   TaskRole
+    }{
+        {"creator", creator, TaskRoleCreator},
+        {"assignee", assignee, TaskRoleAssignee},
+        {"non-member", outsider, TaskRoleNone},
+        {"anonymous", primitive.NilObjectID, TaskRoleNone},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := RoleForTask(task, tt.userID); got != tt.want {
+                t.Errorf("RoleForTask() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCanUpdateField(t *testing.T) {
+    tests := []struct {
+        field                           string
+        creator, assignee, nonMember    bool
+    }{
+        {"title", true, false, false},
+        {"description", true, false, false},
+        {"priority", true, false, false},
+        {"assigned_to", true, false, false},
+        {"due_date", true, false, false},
+        {"status", true, true, false},
+        {"progress", true, true, false},
+        {"rank", true, true, false},
+        {"_id", false, false, false},
+        {"created_by", false, false, false},
+        {"created_at", false, false, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.field, func(t *testing.T) {
+            for role, want := range map[TaskRole]bool{
+                TaskRoleCreator:  tt.creator,
+                TaskRoleAssignee: tt.assignee,
+                TaskRoleNone:     tt.nonMember,
+            } {
+                if got := role.CanUpdateField(tt.field); got != want {
+                    t.Errorf("%v.CanUpdateField(%q) = %v, want %v", role, tt.field, got, want)
+                }
+            }
+        })
+    }
+}
+
+func TestDeniedFields(t *testing.T) {
+    fields := []string{"title", "status", "progress", "created_by"}
+
+    tests := []struct {
+        role TaskRole
+        want []string
+    }{
+        {TaskRoleCreator, []string{"created_by"}},
+        {TaskRoleAssignee, []string{"created_by", "title"}},
+        {TaskRoleNone, []string{"created_by", "progress", "status", "title"}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.role.String(), func(t *testing.T) {
+            if got := tt.role.DeniedFields(fields); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("DeniedFields() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+
+    if got := TaskRoleAssignee.DeniedFields([]string{"status", "progress"}); len(got) != 0 {
+        t.Errorf("assignee DeniedFields(status, progress) = %v, want none", got)
+    }
+}
+
+func TestCanDeleteAndView(t *testing.T) {
+    tests := []struct {
+        role            TaskRole
+        canDelete, view bool
+    }{
+        {TaskRoleCreator, true, true},
+        {TaskRoleAssignee, false, true},
+        {TaskRoleNone, false, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.role.String(), func(t *testing.T) {
+            if got := tt.role.CanDelete(); got != tt.canDelete {
+                t.Errorf("CanDelete() = %v, want %v", got, tt.canDelete)
+            }
+            if got := tt.role.CanView(); got != tt.view {
+                t.Errorf("CanView() = %v, want %v", got, tt.view)
+            }
+        })
+    }
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+    "regexp"
+    "sort"
+)
+
+// templateVariablePattern matches {{name}}, allowing spaces inside the braces
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// RenderTemplate replaces every {{name}} in text with vars[name]. It also
+// returns the sorted names that had no value; those are left as written.
+func RenderTemplate(text string, vars map[string]string) (string, []string) {
+    missing := make(map[string]bool)
+    rendered := templateVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+        name := templateVariablePattern.FindStringSubmatch(match)[1]
+        value, ok := vars[name]
+        if !ok {
+            missing[name] = true
+            return match
+        }
+        return value
+    })
+
+    var names []string
+    for name := range missing {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return rendered, names
+}
@@ -25,17 +25,25 @@ type Client struct {
 type Hub struct {
     Clients    map[*Client]bool
     Broadcast  chan []byte
+    Direct     chan Envelope
     Register   chan *Client
     Unregister chan *Client
     mutex      sync.RWMutex
 }
 
+// Envelope is a message addressed to specific users rather than everyone
+type Envelope struct {
+    UserIDs []string
+    Message []byte
+}
+
 var WebsocketHub = NewHub()
 
 func NewHub() *Hub {
     return &Hub{
         Clients:    make(map[*Client]bool),
         Broadcast:  make(chan []byte),
+        Direct:     make(chan Envelope, 256),
         Register:   make(chan *Client),
         Unregister: make(chan *Client),
     }
@@ -70,6 +78,26 @@ func (h *Hub) Run() {
                 }
             }
             h.mutex.RUnlock()
+
+        case envelope := <-h.Direct:
+            recipients := make(map[string]bool, len(envelope.UserIDs))
+            for _, id := range envelope.UserIDs {
+                recipients[id] = true
+            }
+
+            h.mutex.Lock()
+            for client := range h.Clients {
+                if !recipients[client.ID] {
+                    continue
+                }
+                select {
+                case client.Send <- envelope.Message:
+                default:
+                    close(client.Send)
+                    delete(h.Clients, client)
+                }
+            }
+            h.mutex.Unlock()
         }
     }
 }
@@ -160,3 +188,24 @@ func BroadcastMessage(messageType string, data interface{}) {
 
     WebsocketHub.Broadcast <- jsonMessage
 }
+
+// SendToUsers delivers a message only to the connections of the given users
+func SendToUsers(userIDs []string, messageType string, data interface{}) {
+    if len(userIDs) == 0 {
+        return
+    }
+
+    message := map[string]interface{}{
+        "type":      messageType,
+        "data":      data,
+        "timestamp": time.Now().Format(time.RFC3339),
+    }
+
+    jsonMessage, err := json.Marshal(message)
+    if err != nil {
+        log.Printf("Error marshaling message: %v", err)
+        return
+    }
+
+    WebsocketHub.Direct <- Envelope{UserIDs: userIDs, Message: jsonMessage}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+    "fmt"
+    "strings"
+
+    "backend-trackit/models"
+)
+
+// Reasons a status transition can be refused
+const (
+    TransitionIllegal          = "illegal_transition"
+    TransitionCommentRequired  = "comment_required"
+    TransitionAssigneeRequired = "assignee_required"
+)
+
+// TransitionError explains why a status change was refused
+type TransitionError struct {
+    Reason  string   `json:"reason"`
+    From    string   `json:"from"`
+    To      string   `json:"to"`
+    Allowed []string `json:"allowed_next_states"`
+}
+
+func (e *TransitionError) Error() string {
+    switch e.Reason {
+    case TransitionCommentRequired:
+        return fmt.Sprintf("moving from %s to %s requires a comment", e.From, e.To)
+    case TransitionAssigneeRequired:
+        return fmt.Sprintf("moving from %s to %s requires an assignee", e.From, e.To)
+    default:
+        return fmt.Sprintf("cannot move from %s to %s, allowed: %s", e.From, e.To, strings.Join(e.Allowed, ", "))
+    }
+}
+
+// CheckTransition decides whether a task may move from one status to
+// another under the workflow. Staying in the same status is always allowed.
+func CheckTransition(workflow models.Workflow, from, to string, hasAssignee bool, comment string) error {
+    if from == to {
+        return nil
+    }
+
+    allowed := workflow.NextStates(from)
+    refuse := func(reason string) error {
+        return &TransitionError{Reason: reason, From: from, To: to, Allowed: allowed}
+    }
+
+    if !workflow.HasState(to) {
+        return refuse(TransitionIllegal)
+    }
+
+    // Tasks whose status predates the workflow may join it at any state
+    if !workflow.HasState(from) {
+        return nil
+    }
+
+    transition, ok := workflow.FindTransition(from, to)
+    if !ok {
+        return refuse(TransitionIllegal)
+    }
+    if transition.RequiresAssignee && !hasAssignee {
+        return refuse(TransitionAssigneeRequired)
+    }
+    if transition.RequiresComment && strings.TrimSpace(comment) == "" {
+        return refuse(TransitionCommentRequired)
+    }
+    return nil
+}
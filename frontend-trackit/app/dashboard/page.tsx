@@ -83,7 +83,7 @@ export default function Dashboard() {
             (acc, task) => {
                 acc.total++;
                 switch (task.status) {
-                    case 'completed':
+                    case 'done':
                         acc.completed++;
                         break;
                     case 'in_progress':
@@ -165,7 +165,9 @@ export default function TasksPage() {
                                     <option value="all">All Status</option>
                                     <option value="todo">Todo</option>
                                     <option value="in_progress">In Progress</option>
-                                    <option value="completed">Completed</option>
+                                    <option value="blocked">Blocked</option>
+                                    <option value="done">Done</option>
+                                    <option value="cancelled">Cancelled</option>
                                 </select>
                                 <select
                                     value={filters.priority}
@@ -176,6 +178,7 @@ export default function TasksPage() {
                                     <option value="low">Low</option>
                                     <option value="medium">Medium</option>
                                     <option value="high">High</option>
+                                    <option value="urgent">Urgent</option>
                                 </select>
                             </div>
                         </div>
@@ -229,13 +232,16 @@ const TaskCard = ({ task, onUpdate, onDelete }: {
     const statusColors = {
         todo: 'bg-gray-100 text-gray-800',
         in_progress: 'bg-yellow-100 text-yellow-800',
-        completed: 'bg-green-100 text-green-800',
+        blocked: 'bg-red-100 text-red-800',
+        done: 'bg-green-100 text-green-800',
+        cancelled: 'bg-gray-100 text-gray-500',
     };
 
     const priorityColors = {
         low: 'bg-blue-100 text-blue-800',
         medium: 'bg-orange-100 text-orange-800',
         high: 'bg-red-100 text-red-800',
+        urgent: 'bg-red-200 text-red-900',
     };
 
     return (
@@ -270,7 +276,9 @@ const TaskCard = ({ task, onUpdate, onDelete }: {
                     >
                         <option value="todo">Todo</option>
                         <option value="in_progress">In Progress</option>
-                        <option value="completed">Completed</option>
+                        <option value="blocked">Blocked</option>
+                        <option value="done">Done</option>
+                        <option value="cancelled">Cancelled</option>
                     </select>
                     <button
                         onClick={() => onDelete(task.id)}
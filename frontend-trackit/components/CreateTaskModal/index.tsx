@@ -127,6 +127,7 @@ export default function CreateTaskModal({
                                             <option value="low">Low</option>
                                             <option value="medium">Medium</option>
                                             <option value="high">High</option>
+                                            <option value="urgent">Urgent</option>
                                         </select>
                                     </div>
 
@@ -4,18 +4,22 @@ import { motion } from 'framer-motion'
 import KanbanCard from './KanbanCard'
 import { Task } from '@/types'
 
-const statuses = ['todo', 'in_progress', 'completed']
+const statuses = ['todo', 'in_progress', 'blocked', 'done', 'cancelled']
 
 const statusLabels = {
   todo: 'To Do',
   in_progress: 'In Progress',
-  completed: 'Completed'
+  blocked: 'Blocked',
+  done: 'Done',
+  cancelled: 'Cancelled'
 }
 
 const statusColors = {
   todo: 'bg-blue-100',
   in_progress: 'bg-yellow-100',
-  completed: 'bg-green-100'
+  blocked: 'bg-red-100',
+  done: 'bg-green-100',
+  cancelled: 'bg-gray-100'
 }
 
 const KanbanBoard = ({ tasks, onUpdateTask, onDeleteTask }: {
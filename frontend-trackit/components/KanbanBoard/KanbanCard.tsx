@@ -86,7 +86,9 @@ const KanbanCard = ({ task, onUpdate, onDelete }: {
             >
               <option value="todo">To Do</option>
               <option value="in_progress">In Progress</option>
-              <option value="completed">Completed</option>
+              <option value="blocked">Blocked</option>
+              <option value="done">Done</option>
+              <option value="cancelled">Cancelled</option>
             </select>
           </div>
         </>
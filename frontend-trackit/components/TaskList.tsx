@@ -26,6 +26,8 @@ export default function TaskList({ tasks, onTaskUpdate }: TaskListProps) {
 
   const getPriorityColor = (priority: string) => {
     switch (priority) {
+      case 'urgent':
+        return 'text-red-700';
       case 'high':
         return 'text-red-500';
       case 'medium':
@@ -37,7 +39,7 @@ export default function TaskList({ tasks, onTaskUpdate }: TaskListProps) {
 
   const getStatusIcon = (status: string) => {
     switch (status) {
-      case 'completed':
+      case 'done':
         return <CheckCircleIcon className="h-5 w-5 text-green-500" />;
       case 'in_progress':
         return <ClockIcon className="h-5 w-5 text-yellow-500" />;
@@ -75,7 +77,9 @@ export default function TaskList({ tasks, onTaskUpdate }: TaskListProps) {
               >
                 <option value="todo">Todo</option>
                 <option value="in_progress">In Progress</option>
-                <option value="completed">Completed</option>
+                <option value="blocked">Blocked</option>
+                <option value="done">Done</option>
+                <option value="cancelled">Cancelled</option>
               </select>
             </div>
 
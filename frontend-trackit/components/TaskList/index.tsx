@@ -39,7 +39,9 @@ const TaskList: React.FC<TaskListProps> = ({ tasks, onUpdateTask, onDeleteTask }
                                 >
                                     <option value="todo">Todo</option>
                                     <option value="in_progress">In Progress</option>
-                                    <option value="completed">Completed</option>
+                                    <option value="blocked">Blocked</option>
+                                    <option value="done">Done</option>
+                                    <option value="cancelled">Cancelled</option>
                                 </select>
                                 <button
                                     onClick={() => onDeleteTask(task.id)}
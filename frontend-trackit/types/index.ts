@@ -2,11 +2,11 @@ export interface Task {
   id: string;
     title: string;
     description: string;
-    status: 'todo' | 'in_progress' | 'completed';
-    priority: 'low' | 'medium' | 'high';
+    status: 'todo' | 'in_progress' | 'blocked' | 'done' | 'cancelled';
+    priority: 'low' | 'medium' | 'high' | 'urgent';
     created_at?: string;
   due_date?: string;
-  assigned_to?: string;
+  assigned_to?: string[];
   created_by: string;
  
   updated_at: string;